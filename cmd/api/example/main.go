@@ -4,19 +4,21 @@ import (
 	"context"
 	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/joho/godotenv"
-	"github.com/newrelic/go-agent/v3/integrations/nrgrpc"
 	"google.golang.org/grpc"
 	"log"
 	"os"
+	"time"
 
 	exampleRepo "demo/internal/adapter/repository/mysql/example"
 	exampleHlr "demo/internal/router/handler/example"
 	exampleUC "demo/internal/usecase/example"
 	"demo/pkg/bootkit"
-	"demo/pkg/httpkit"
+	"demo/pkg/idempotkit"
 	"demo/pkg/initkit"
 	"demo/pkg/logger"
+	"demo/pkg/ratekit"
 	"demo/pkg/servkit"
+	apiDocs "demo/proto"
 	examplePb "demo/proto/example"
 )
 
@@ -31,8 +33,8 @@ func main() {
 	flushLog := initkit.InitLogger()
 	defer flushLog()
 
-	// newrelic.Application
-	nrApp := initkit.NewNewRelicApp()
+	// telemetry provider selected by TELEMETRY_PROVIDER
+	initkit.NewTelemetry()
 
 	// mysql
 	db := initkit.NewGormDB()
@@ -40,9 +42,6 @@ func main() {
 	// migration db in development env
 	initkit.ExecMySQLMigration()
 
-	// == middlewares ==
-	nrHandler := httpkit.NewNRHttpHandler(nrApp)
-
 	// == business handlers ==
 	exampleRepo := exampleRepo.NewExampleRepository(db)
 	exampleUC := exampleUC.NewExampleUsecase(exampleRepo)
@@ -51,21 +50,44 @@ func main() {
 	// == run apis ==
 	ctx := context.Background()
 
+	// re-read .env on SIGHUP
+	bootkit.AddReloadHandler("dotenv", func(ctx context.Context) error {
+		return godotenv.Overload()
+	})
+
+	// throttle brute-force logins per client
+	loginLimiter, err := ratekit.NewSlidingWindow(ratekit.Rate{Limit: 10, Period: time.Minute})
+	if err != nil {
+		logger.Ctx(ctx).Fatal("ratekit.NewSlidingWindow failed", logger.WithError(err))
+	}
+
+	// replay responses of retried requests with the same Idempotency-Key
+	sqlDB, err := db.DB()
+	if err != nil {
+		logger.Ctx(ctx).Fatal("db.DB failed", logger.WithError(err))
+	}
+	idempotencyStore := idempotkit.NewMySQLStore(sqlDB)
+
 	grpcAdd := os.Getenv("GRPC_ADDR")
 	grpcGWAdd := os.Getenv("GRPC_GW_ADDR")
-	bootkit.Register(func(shutdownFn bootkit.ShutdownFunc) error {
+	// the gateway calls the gRPC server in memory instead of over loopback
+	inProcess := servkit.NewInProcess()
+	bootkit.RegisterServer("grpc-server", func(shutdownFn bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
 		return servkit.RunGrpcServer(ctx, grpcAdd, shutdownFn,
 			func(s *grpc.Server) {
 				examplePb.RegisterExampleServer(s, exampleHlr)
 			},
-			servkit.WithGrpcServOptions(
-				grpc.ChainUnaryInterceptor(nrgrpc.UnaryServerInterceptor(nrApp)),
-				grpc.ChainStreamInterceptor(nrgrpc.StreamServerInterceptor(nrApp)),
-			),
+			servkit.WithDeadline(30*time.Second, time.Minute),
+			servkit.WithRateLimit(loginLimiter, servkit.KeyByRealIP(), "/example.Example/Login"),
+			servkit.WithIdempotency(idempotencyStore, 24*time.Hour),
+			servkit.WithHealth(0),
+			servkit.WithReflection(),
+			servkit.WithInProcess(inProcess),
+			servkit.WithReady(ready),
 		)
 	})
 
-	bootkit.Register(func(shutdownFn bootkit.ShutdownFunc) error {
+	bootkit.RegisterServer("grpc-gateway", func(shutdownFn bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
 		return servkit.RunGrpcGateway(ctx, grpcGWAdd, grpcAdd, shutdownFn,
 			func(ctx context.Context, mux *gwruntime.ServeMux, conn *grpc.ClientConn) error {
 				for _, f := range []func(context.Context, *gwruntime.ServeMux, *grpc.ClientConn) error{
@@ -78,7 +100,6 @@ func main() {
 
 				return nil
 			},
-			servkit.WithMiddlewares(nrHandler.Handle),
 			servkit.WithGWServMuxOptions(
 				servkit.SetFormULREncodeMarhslalerOptions(),
 				servkit.SetDefaultMarshalerOptions(),
@@ -86,14 +107,14 @@ func main() {
 					"X-Custom-Header": {}, // forward `X-Custom-Header` plus permanent headers from http request into gRPC metadata
 				}),
 				servkit.OverrideResponseStatusCode(),
-				servkit.RegisterHTTPErrorHandler(),
-			),
-			servkit.WithGrpcDialOptions(
-				grpc.WithUnaryInterceptor(nrgrpc.UnaryClientInterceptor),
-				grpc.WithStreamInterceptor(nrgrpc.StreamClientInterceptor),
+				servkit.RegisterHTTPErrorHandler(), // errors in the envelope of proto/envelope
 			),
+			servkit.WithInProcess(inProcess),
+			// Swagger UI at /docs except production
+			servkit.WithOpenAPI(apiDocs.OpenAPI),
+			servkit.WithReady(ready),
 		)
-	})
+	}, bootkit.WithDependsOn("grpc-server"))
 
 	if err := bootkit.ListenAndServe(ctx); err != nil {
 		logger.Ctx(ctx).Fatal("bootkit.ListenAndServe failed", logger.WithError(err))
@@ -0,0 +1,11 @@
+-- +goose Up
+-- responses of requests by their idempotency keys, response is NULL while the request is in flight
+CREATE TABLE idempotency_keys (
+    idem_key VARCHAR(255) NOT NULL PRIMARY KEY,
+    response MEDIUMBLOB NULL,
+    expire_at DATETIME(6) NOT NULL,
+    INDEX idx_expire_at (expire_at)
+);
+
+-- +goose Down
+DROP TABLE idempotency_keys;
@@ -21,16 +21,6 @@ func NewExampleHandler(usecase uc.ExampleUsecase) pb.ExampleServer {
 }
 
 func (h *exampleHandler) Login(ctx context.Context, req *pb.LoginReq) (*pb.LoginResp, error) {
-	if err := req.Validate(); err != nil {
-		logger.Ctx(ctx).Error("Validate failed", logger.WithError(err))
-		return handler.AbortWithError(ctx, err, &pb.LoginResp{
-			Status:      "failed",
-			Description: "bad request",
-		},
-			handler.WithHttpStatus(http.StatusBadRequest),
-		)
-	}
-
 	if err := h.usecase.Login(ctx, req.Username, req.Password); err != nil {
 		logger.Ctx(ctx).Error("usecase.Login failed", logger.WithError(err))
 		return handler.AbortWithError(ctx, err, &pb.LoginResp{
@@ -48,16 +38,6 @@ func (h *exampleHandler) Login(ctx context.Context, req *pb.LoginReq) (*pb.Login
 }
 
 func (h *exampleHandler) ListItems(ctx context.Context, req *pb.ListItemsReq) (*pb.ListItemsResp, error) {
-	if err := req.Validate(); err != nil {
-		logger.Ctx(ctx).Error("Validate failed", logger.WithError(err))
-		return handler.AbortWithError(ctx, err, &pb.ListItemsResp{
-			Status: "failed",
-			Item:   nil,
-		},
-			handler.WithHttpStatus(http.StatusBadRequest),
-		)
-	}
-
 	items, err := h.usecase.ListItems(ctx, req.Username, req.Item)
 	if err != nil {
 		logger.Ctx(ctx).Error("usecase.ListRecords failed", logger.WithError(err))
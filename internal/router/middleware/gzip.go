@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"demo/pkg/logger"
+	"demo/pkg/redactkit"
 )
 
 func GZipDecompressor(h http.Handler) http.Handler {
@@ -29,7 +30,7 @@ func GZipDecompressor(h http.Handler) http.Handler {
 			}
 			r.Body = io.NopCloser(bytes.NewReader(decompressedData))
 
-			logger.Ctx(r.Context()).Info(fmt.Sprintf("Decompressed request body: %s", string(decompressedData)))
+			logger.Ctx(r.Context()).Info(fmt.Sprintf("Decompressed request body: %s", redactkit.Body(r.Header.Get("Content-Type"), decompressedData)))
 		}
 
 		h.ServeHTTP(w, r)
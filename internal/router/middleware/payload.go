@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"demo/pkg/logger"
+	"demo/pkg/redactkit"
 )
 
 func PayloadMiddleware(h http.Handler) http.Handler {
@@ -19,7 +20,7 @@ func PayloadMiddleware(h http.Handler) http.Handler {
 		r.Header.Set("Custom-Request-Payload", string(buf.Bytes()))
 
 		if buf.Bytes() != nil {
-			logger.Ctx(r.Context()).Info(fmt.Sprintf("request body: %s", string(buf.Bytes())))
+			logger.Ctx(r.Context()).Info(fmt.Sprintf("request body: %s", redactkit.Body(r.Header.Get("Content-Type"), buf.Bytes())))
 		}
 
 		h.ServeHTTP(w, r)
@@ -0,0 +1,425 @@
+package bootkit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"demo/pkg/logger"
+)
+
+// App owns the state of booting up and shutting down a set of servers.
+// Each App runs once. Create another one with New() to boot the servers again in the same process.
+type App struct {
+	// shutdownLevel is used to prioritize the order of shutdownHandlers.
+	// The same level of callback function will be triggered at the same time.
+	// Default level is zero.
+	shutdownHandlers map[int][]*shutdownHandler
+	shutdownHlrMux   sync.Mutex
+	shutdownReport   ShutdownReport
+
+	serveHandlers []*server
+	servHlrMux    sync.Mutex
+	servHlrWg     sync.WaitGroup
+
+	probes   []*probe
+	probeMux sync.RWMutex
+
+	reloadHandlers []*reloadHandler
+	reloadHlrMux   sync.Mutex
+	reloadMux      sync.Mutex
+
+	lifecycle    lifecycle
+	lifecycleMux sync.RWMutex
+
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+
+	wait chan struct{}
+}
+
+// New returns an App with its own servers, shutdown handlers and probes.
+func New() *App {
+	return &App{
+		shutdownHandlers: map[int][]*shutdownHandler{},
+		serveHandlers:    []*server{},
+		probes:           []*probe{},
+		reloadHandlers:   []*reloadHandler{},
+		lifecycle: lifecycle{
+			state:   LifecycleStateIdle,
+			servers: []*ServerStatus{},
+			events:  []Event{},
+		},
+		wait: make(chan struct{}),
+	}
+}
+
+// AddShutdownHandler hooks callback function that want to be called on app shutdown.
+func (a *App) AddShutdownHandler(handler handlerFunc, options ...BootOptions) {
+	a.addHandler(handler, options...)
+}
+
+func (a *App) addHandler(handler handlerFunc, options ...BootOptions) *shutdownHandler {
+	o := applyServOptions(options...)
+
+	a.shutdownHlrMux.Lock()
+	defer a.shutdownHlrMux.Unlock()
+
+	name := o.name
+	if name == "" {
+		name = fmt.Sprintf("level-%d-%d", o.level, len(a.shutdownHandlers[o.level]))
+	}
+
+	h := &shutdownHandler{
+		name:    name,
+		level:   o.level,
+		timeout: o.timeout,
+		fn:      handler,
+	}
+	a.shutdownHandlers[o.level] = append(a.shutdownHandlers[o.level], h)
+
+	return h
+}
+
+// removeHandlers removes shutdown handlers of a server which is down and going to restart.
+func (a *App) removeHandlers(hs []*shutdownHandler) {
+	a.shutdownHlrMux.Lock()
+	defer a.shutdownHlrMux.Unlock()
+
+	for _, h := range hs {
+		hlrs := a.shutdownHandlers[h.level]
+		for i := range hlrs {
+			if hlrs[i] == h {
+				a.shutdownHandlers[h.level] = append(hlrs[:i:i], hlrs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// LastShutdownReport returns the report of the latest shutdown process.
+// It's available after Wait() returns.
+func (a *App) LastShutdownReport() ShutdownReport {
+	a.shutdownHlrMux.Lock()
+	defer a.shutdownHlrMux.Unlock()
+
+	return a.shutdownReport
+}
+
+// Register registers the callback function launching a server running in a infinite loop. ex: http.ListenAndServe()
+// The server is regarded as ready once it starts. Use RegisterServer() to signal readiness explicitly.
+func (a *App) Register(handler registerFunc, options ...RegisterOptions) {
+	a.servHlrMux.Lock()
+	defer a.servHlrMux.Unlock()
+
+	// the name is picked and taken under the same lock, so concurrent calls never share it
+	n := len(a.serveHandlers)
+	name := fmt.Sprintf("server-%d", n)
+	for a.registered(name) {
+		n++
+		name = fmt.Sprintf("server-%d", n)
+	}
+
+	a.registerServer(name, func(shutdown ShutdownFunc, ready ReadyFunc) error {
+		ready()
+		return handler(shutdown)
+	}, options...)
+}
+
+// RegisterServer registers the named callback function launching a server running in a infinite loop,
+// which calls ready() once it's able to serve. Other servers can depend on it with WithDependsOn(name).
+func (a *App) RegisterServer(name string, handler ServeFunc, options ...RegisterOptions) {
+	a.servHlrMux.Lock()
+	defer a.servHlrMux.Unlock()
+
+	a.registerServer(name, handler, options...)
+}
+
+// registered reports whether a server with the name is registered, a.servHlrMux must be held.
+func (a *App) registered(name string) bool {
+	for _, s := range a.serveHandlers {
+		if s.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// registerServer appends the server, a.servHlrMux must be held.
+func (a *App) registerServer(name string, handler ServeFunc, options ...RegisterOptions) {
+	o := applyRegisterOptions(options...)
+
+	a.serveHandlers = append(a.serveHandlers, &server{
+		name:        name,
+		dependsOn:   o.dependsOn,
+		fn:          handler,
+		policy:      o.policy,
+		backoff:     o.backoff,
+		maxBackoff:  o.maxBackoff,
+		maxRestarts: o.maxRestarts,
+		window:      o.window,
+		ready:       make(chan struct{}),
+	})
+
+	a.record(Event{Type: EventTypeRegistered, Server: name}, nil)
+}
+
+// Run launches all servers registered above, and returns once all of them are ready.
+// A server starts after all servers it depends on are ready.
+// It fails with the name of the server which returned an error or didn't become ready within the startup timeout.
+// The options configure the startup and shutdown process, ex: WithStartupTimeout(), WithShutdownBudget().
+func (a *App) Run(ctx context.Context, options ...BootOptions) error {
+	o := applyServOptions(options...)
+
+	a.servHlrMux.Lock()
+	defer a.servHlrMux.Unlock()
+
+	if len(a.serveHandlers) == 0 {
+		return ErrNoRegister
+	}
+
+	if err := validateServers(a.serveHandlers); err != nil {
+		return err
+	}
+	assignShutdownLevels(a.serveHandlers)
+
+	if !a.started.CompareAndSwap(false, true) {
+		return ErrAlreadyRun
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	a.fireShutdownHandlers(ctx, o)
+
+	servers := map[string]*server{}
+	for _, serv := range a.serveHandlers {
+		servers[serv.name] = serv
+	}
+
+	now := time.Now()
+	readyCh := make(chan *server, len(a.serveHandlers))
+	errCh := make(chan error, len(a.serveHandlers))
+	for i := range a.serveHandlers {
+		serv := a.serveHandlers[i]
+		a.servHlrWg.Add(1)
+		go func() {
+			defer a.servHlrWg.Done()
+
+			// wait until all dependencies are ready
+			for _, dep := range serv.dependsOn {
+				select {
+				case <-servers[dep].ready:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			ready := func() {
+				serv.readyOnce.Do(func() {
+					a.record(Event{
+						Type:     EventTypeReady,
+						Server:   serv.name,
+						Duration: time.Since(serv.startedAt).String(),
+					}, nil)
+					close(serv.ready)
+					readyCh <- serv
+				})
+			}
+
+			if err := a.supervise(ctx, serv, ready); err != nil {
+				errCh <- fmt.Errorf("%s: %w", serv.name, err)
+				return
+			}
+
+			// a server going down without error doesn't block the others.
+			ready()
+		}()
+	}
+
+	timer := time.NewTimer(o.startupTimeout)
+	defer timer.Stop()
+
+	for pending := len(a.serveHandlers); pending > 0; pending-- {
+		select {
+		case <-readyCh:
+		case err := <-errCh:
+			logger.Ctx(ctx).Error("Serve failed", logger.WithError(err))
+			cancel()
+			return err
+		case <-timer.C:
+			err := fmt.Errorf("%w: %s", ErrStartupTimeout, strings.Join(notReady(a.serveHandlers), ", "))
+			logger.Ctx(ctx).Error("Serve failed", logger.WithError(err))
+			cancel()
+			return err
+		case <-ctx.Done():
+			cancel()
+			return ctx.Err()
+		}
+	}
+
+	a.record(Event{Type: EventTypeStarted, Duration: time.Since(now).String()}, nil)
+
+	// shut down the app once any server fails after startup.
+	go func() {
+		defer cancel()
+
+		select {
+		case err := <-errCh:
+			logger.Ctx(ctx).Error("Serve failed", logger.WithError(err))
+		case <-a.wait:
+		}
+	}()
+
+	return nil
+}
+
+// Wait until all shutdown handlers added by AddShutdownHandler() finished.
+func (a *App) Wait() {
+	<-a.wait
+}
+
+func (a *App) fireShutdownHandlers(ctx context.Context, o *bootOptions) {
+	termSignals := make(chan os.Signal, 1)
+	allSignals := make(chan os.Signal, 1)
+
+	// syscall.SIGKILL cannot be trapped by linter. remove it
+	signal.Notify(termSignals, syscall.SIGTERM, syscall.SIGINT)
+	signal.Notify(allSignals)
+
+	// done stops the goroutines watching signals once shutdown handlers finished.
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			var sig os.Signal
+			select {
+			case sig = <-allSignals:
+			case <-done:
+				return
+			}
+
+			switch sig {
+			case syscall.SIGCHLD:
+				fallthrough
+			case syscall.SIGURG:
+				continue
+			case syscall.SIGHUP:
+				logger.Info(fmt.Sprintf("got signal: %v, reloading", sig.String()))
+				go a.Reload(ctx)
+				continue
+			default:
+			}
+			logger.Info(fmt.Sprintf("got signal: %v", sig.String()))
+		}
+	}()
+
+	go func() {
+		signalled := false
+		select {
+		case sig := <-termSignals:
+			signalled = true
+			a.record(Event{Type: EventTypeShutdownStarted, Reason: fmt.Sprintf("got terminational signal: %v", sig.String())}, nil)
+		case <-ctx.Done():
+			a.record(Event{Type: EventTypeShutdownStarted, Reason: fmt.Sprintf("terminated system manually: %v", ctx.Err())}, nil)
+		}
+
+		// fail readiness probes first so that traffic can be drained before servers stop.
+		a.shuttingDown.Store(true)
+		now := time.Now()
+
+		var budgetDeadline time.Time
+		if o.budget > 0 {
+			budgetDeadline = now.Add(o.budget)
+		}
+
+		// another terminational signal during shutdown forces an immediate exit.
+		defer signal.Stop(allSignals)
+		defer signal.Stop(termSignals)
+		defer close(done)
+		go func() {
+			select {
+			case sig := <-termSignals:
+				logger.Error(fmt.Sprintf("got terminational signal again: %v, force exit", sig.String()))
+				_ = logger.Flush()
+				exit(1)
+			case <-done:
+			}
+		}()
+
+		// wait until k8s removes the endpoint of the failed readiness, within the budget.
+		if delay := o.drainDelay; signalled && delay > 0 {
+			if !budgetDeadline.IsZero() {
+				delay = min(delay, time.Until(budgetDeadline))
+			}
+			logger.Info("waiting for traffic to drain before shutting down", logger.WithField("delay", delay.String()))
+			time.Sleep(delay)
+		}
+
+		a.shutdownHlrMux.Lock()
+		defer a.shutdownHlrMux.Unlock()
+
+		ordered := []orderedHandler{}
+		for l, hlrs := range a.shutdownHandlers {
+			ordered = append(ordered, orderedHandler{
+				level:    l,
+				handlers: hlrs,
+			})
+		}
+		// sort in ascending order
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].level < ordered[j].level })
+
+		finished := func(ret ShutdownResult) {
+			level := ret.Level
+			a.record(Event{
+				Type:     EventTypeHandlerFinished,
+				Handler:  ret.Name,
+				Level:    &level,
+				Duration: ret.Duration.String(),
+			}, ret.Err)
+		}
+
+		results := []ShutdownResult{}
+		for _, oh := range ordered {
+			if !budgetDeadline.IsZero() && !time.Now().Before(budgetDeadline) {
+				skipped := oh.skip()
+				for _, ret := range skipped {
+					finished(ret)
+				}
+				results = append(results, skipped...)
+				continue
+			}
+
+			deadline := budgetDeadline
+			if t, ok := o.levelTimeouts[oh.level]; ok && t > 0 {
+				deadline = earlier(deadline, time.Now().Add(t))
+			}
+
+			level := oh.level
+			a.record(Event{Type: EventTypeLevelStarted, Level: &level}, nil)
+			results = append(results, oh.run(deadline, finished)...)
+		}
+
+		a.shutdownReport = ShutdownReport{
+			Duration: time.Since(now),
+			Results:  results,
+		}
+
+		fs := logger.Fields{
+			"duration": a.shutdownReport.Duration.String(),
+			"timedOut": a.shutdownReport.TimedOut(),
+			"skipped":  a.shutdownReport.Skipped(),
+		}
+		if len(a.shutdownReport.TimedOut()) > 0 || len(a.shutdownReport.Skipped()) > 0 {
+			logger.Error("shutdown callbacks didn't finish in time", logger.WithFields(fs))
+		}
+		a.record(Event{Type: EventTypeShutdownFinished, Duration: a.shutdownReport.Duration.String()}, nil)
+		close(a.wait)
+	}()
+}
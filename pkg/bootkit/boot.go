@@ -3,182 +3,101 @@ package bootkit
 import (
 	"context"
 	"errors"
-	"fmt"
 	"os"
-	"os/signal"
-	"sort"
-	"sync"
-	"syscall"
 	"time"
-
-	"demo/pkg/logger"
 )
 
 type handlerFunc func() error
 
 // ShutdownFunc executes the handlerFunc on app shutdown.
-type ShutdownFunc func(handlerFunc)
+type ShutdownFunc func(handlerFunc, ...BootOptions)
 
 type registerFunc func(shutdownHandler ShutdownFunc) error
 
 const (
+	// topPriorityShutdownLevel is the level of servers without dependencies, their dependents go earlier.
 	topPriorityShutdownLevel = -1
+
+	// defaultStartupTimeout is how long Run() waits for all servers to be ready.
+	defaultStartupTimeout = 30 * time.Second
+	// default restart budget and backoff of servers with RestartPolicyOnFailure or RestartPolicyAlways.
+	defaultRestartBackoff    = time.Second
+	defaultRestartMaxBackoff = 30 * time.Second
+	defaultMaxRestarts       = 5
+	defaultRestartWindow     = time.Minute
+
+	// defaultShutdownBudget leaves a few seconds before k8s sends SIGKILL (terminationGracePeriodSeconds: 30).
+	defaultShutdownBudget = 25 * time.Second
+	// defaultReadinessDrainDelay gives k8s a few seconds to remove the endpoint once readiness fails.
+	defaultReadinessDrainDelay = 3 * time.Second
 )
 
 var (
 	// ErrNoRegister indicates no register before running Serve()
 	ErrNoRegister = errors.New("no register")
+	// ErrStartupTimeout indicates some servers didn't become ready within the startup timeout.
+	ErrStartupTimeout = errors.New("startup timed out")
+	// ErrDuplicatedServer indicates more than one server registered with the same name.
+	ErrDuplicatedServer = errors.New("duplicated server")
+	// ErrUnknownDependency indicates a server depends on a server which isn't registered.
+	ErrUnknownDependency = errors.New("unknown dependency")
+	// ErrCyclicDependency indicates servers depend on each other.
+	ErrCyclicDependency = errors.New("cyclic dependency")
+	// ErrRestartBudgetExceeded indicates a server restarted too many times within the window.
+	ErrRestartBudgetExceeded = errors.New("restart budget exceeded")
+	// ErrReloadPanic indicates a reload handler panicked.
+	ErrReloadPanic = errors.New("reload handler panicked")
+	// ErrAlreadyRun indicates the App was run before. Create another one with New() instead.
+	ErrAlreadyRun = errors.New("already run")
+	// ErrShutdownTimeout indicates a shutdown handler didn't finish within its deadline.
+	ErrShutdownTimeout = errors.New("shutdown handler timed out")
+	// ErrShutdownSkipped indicates a shutdown handler didn't run because the shutdown budget was used up.
+	ErrShutdownSkipped = errors.New("shutdown handler skipped")
 )
 
 var (
-	// shutdownLevel is used to prioritize the order of shutdownHandlers.
-	// The same level of callback function will be triggered at the same time.
-	// Default level is zero.
-	shutdownHandlers = map[int][]handlerFunc{}
-	shutdownHlrMux   = sync.Mutex{}
+	// defaultApp is used by the package-level functions.
+	defaultApp = New()
 
-	serveHandlers = []registerFunc{}
-	servHlrMux    = sync.Mutex{}
-	servHlrWg     = sync.WaitGroup{}
-
-	wait = make(chan struct{})
+	// exit is replaced in test case only.
+	exit = os.Exit
 )
 
-type orderedHandler struct {
-	level    int
-	handlers []handlerFunc
+// Default returns the App used by the package-level functions.
+func Default() *App {
+	return defaultApp
 }
 
 // AddShutdownHandler hooks callback function that want to be called on app shutdown.
 func AddShutdownHandler(handler handlerFunc, options ...BootOptions) {
-	o := applyServOptions(options...)
-
-	shutdownHlrMux.Lock()
-	defer shutdownHlrMux.Unlock()
-
-	shutdownHandlers[o.level] = append(shutdownHandlers[o.level], handler)
-}
-
-func addShutdownHandler(handler handlerFunc) {
-	AddShutdownHandler(handler, withTopPriorityShutdownLevel())
+	defaultApp.AddShutdownHandler(handler, options...)
 }
 
-func fireShutdownHandlers(ctx context.Context) {
-	termSignals := make(chan os.Signal)
-	allSignals := make(chan os.Signal)
-
-	// syscall.SIGKILL cannot be trapped by linter. remove it
-	signal.Notify(termSignals, syscall.SIGTERM, syscall.SIGINT)
-	signal.Notify(allSignals)
-
-	go func() {
-		for sig := range allSignals {
-			switch sig {
-			case syscall.SIGCHLD:
-				fallthrough
-			case syscall.SIGURG:
-				continue
-			default:
-			}
-			logger.Info(fmt.Sprintf("got signal: %v", sig.String()))
-		}
-	}()
-
-	go func() {
-		select {
-		case sig := <-termSignals:
-			logger.Error(fmt.Sprintf("got terminational signal: %v", sig.String()))
-		case <-ctx.Done():
-			logger.Error(fmt.Sprintf("terminated system manually: %v", ctx.Err()))
-		}
-
-		shutdownHlrMux.Lock()
-		defer shutdownHlrMux.Unlock()
-
-		now := time.Now()
-
-		ordered := []orderedHandler{}
-		for l, hlrs := range shutdownHandlers {
-			ordered = append(ordered, orderedHandler{
-				level:    l,
-				handlers: hlrs,
-			})
-		}
-		// sort in ascending order
-		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].level < ordered[j].level })
-
-		var wg sync.WaitGroup
-		for _, o := range ordered {
-			// Registered callbacks at the same level run concurrently.
-			// Deliver the last-in handler at the same level as possible.
-			for i := len(o.handlers) - 1; i >= 0; i-- {
-				wg.Add(1)
-				go func(idx int, cb handlerFunc) {
-					defer wg.Done()
-					if err := cb(); err != nil {
-						logger.Error(fmt.Sprintf("shutdown callback function failed: %v", err))
-					}
-					logger.Debug("finish shutdown callback", logger.WithFields(logger.Fields{"level": o.level, "idx": idx}))
-				}(i, o.handlers[i])
-			}
-
-			wg.Wait()
-		}
-		logger.Info(fmt.Sprintf("shutdown callbacks finished within %fs", float64(time.Since(now)/time.Second)))
-		close(wait)
-	}()
+// LastShutdownReport returns the report of the latest shutdown process.
+// It's available after Wait() returns.
+func LastShutdownReport() ShutdownReport {
+	return defaultApp.LastShutdownReport()
 }
 
 // Register registers the callback function launching a server running in a infinite loop. ex: http.ListenAndServe()
-func Register(handler registerFunc) {
-	servHlrMux.Lock()
-	defer servHlrMux.Unlock()
+// The server is regarded as ready once it starts. Use RegisterServer() to signal readiness explicitly.
+func Register(handler registerFunc, options ...RegisterOptions) {
+	defaultApp.Register(handler, options...)
+}
 
-	serveHandlers = append(serveHandlers, handler)
+// RegisterServer registers the named callback function launching a server running in a infinite loop,
+// which calls ready() once it's able to serve. Other servers can depend on it with WithDependsOn(name).
+func RegisterServer(name string, handler ServeFunc, options ...RegisterOptions) {
+	defaultApp.RegisterServer(name, handler, options...)
 }
 
-// ListenAndServe launches all servers registered above at the same time, blockes until all of them down.
-func ListenAndServe(ctx context.Context) error {
-	servHlrMux.Lock()
-	defer servHlrMux.Unlock()
-
-	if len(serveHandlers) == 0 {
-		return ErrNoRegister
-	}
-
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	fireShutdownHandlers(ctx)
-
-	errCh := make(chan error, len(serveHandlers))
-	for i := range serveHandlers {
-		serv := serveHandlers[i]
-		servHlrWg.Add(1)
-		go func() {
-			defer servHlrWg.Done()
-			if err := serv(addShutdownHandler); err != nil {
-				errCh <- err
-			}
-		}()
-	}
-
-	select {
-	case err := <-errCh:
-		logger.Ctx(ctx).Error("Serve failed", logger.WithError(err))
-		cancel()
-		return err
-	case <-time.After(5 * time.Second):
-		logger.Info("start serving without error within 5 seconds")
-	}
-
-	// wait until all servers are down.
-	servHlrWg.Wait()
-
-	return nil
+// ListenAndServe launches all servers registered above, and returns once all of them are ready.
+// The options configure the startup and shutdown process, ex: WithStartupTimeout(), WithShutdownBudget().
+func ListenAndServe(ctx context.Context, options ...BootOptions) error {
+	return defaultApp.Run(ctx, options...)
 }
 
 // Wait until all shutdown handlers added by AddShutdownHandler() finished.
 func Wait() {
-	<-wait
+	defaultApp.Wait()
 }
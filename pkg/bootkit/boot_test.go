@@ -0,0 +1,576 @@
+package bootkit
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type bootSuite struct {
+	suite.Suite
+
+	app *App
+}
+
+func (s *bootSuite) SetupSuite()    {}
+func (s *bootSuite) TearDownSuite() {}
+func (s *bootSuite) SetupTest() {
+	s.app = New()
+	exit = func(int) {}
+}
+func (s *bootSuite) TearDownTest() {}
+
+func TestBootSuite(t *testing.T) {
+	suite.Run(t, new(bootSuite))
+}
+
+func (s *bootSuite) TestFireShutdownHandlers() {
+	hung := func() error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	tests := []struct {
+		Desc        string
+		SetupTest   func(*App)
+		Options     []BootOptions
+		ExpTimedOut []string
+		ExpSkipped  []string
+		ExpErrs     map[string]error
+	}{
+		{
+			Desc: "all finished",
+			SetupTest: func(app *App) {
+				app.AddShutdownHandler(func() error { return nil }, WithShutdownName("db"))
+				app.AddShutdownHandler(func() error { return errors.New("closed") }, WithShutdownName("cache"), WithShutdownLevel(1))
+			},
+			ExpTimedOut: []string{},
+			ExpSkipped:  []string{},
+			ExpErrs:     map[string]error{"db": nil, "cache": errors.New("closed")},
+		},
+		{
+			Desc: "handler timed out",
+			SetupTest: func(app *App) {
+				app.AddShutdownHandler(hung, WithShutdownName("db"), WithShutdownTimeout(10*time.Millisecond))
+				app.AddShutdownHandler(func() error { return nil }, WithShutdownName("cache"), WithShutdownLevel(1))
+			},
+			ExpTimedOut: []string{"db"},
+			ExpSkipped:  []string{},
+			ExpErrs:     map[string]error{"db": ErrShutdownTimeout, "cache": nil},
+		},
+		{
+			Desc: "level timed out",
+			SetupTest: func(app *App) {
+				app.AddShutdownHandler(hung, WithShutdownName("db"), WithShutdownLevel(1))
+				app.AddShutdownHandler(hung, WithShutdownName("cache"), WithShutdownLevel(1))
+			},
+			Options:     []BootOptions{WithShutdownLevelTimeout(1, 10*time.Millisecond)},
+			ExpTimedOut: []string{"db", "cache"},
+			ExpSkipped:  []string{},
+			ExpErrs:     map[string]error{"db": ErrShutdownTimeout, "cache": ErrShutdownTimeout},
+		},
+		{
+			Desc: "server level timed out",
+			SetupTest: func(app *App) {
+				app.AddShutdownHandler(hung, WithShutdownName("grpc"), withShutdownLevel(topPriorityShutdownLevel))
+				app.AddShutdownHandler(func() error { return nil }, WithShutdownName("db"))
+			},
+			Options:     []BootOptions{WithShutdownLevelTimeout(-1, 10*time.Millisecond)},
+			ExpTimedOut: []string{"grpc"},
+			ExpSkipped:  []string{},
+			ExpErrs:     map[string]error{"grpc": ErrShutdownTimeout, "db": nil},
+		},
+		{
+			Desc: "budget used up",
+			SetupTest: func(app *App) {
+				app.AddShutdownHandler(hung, WithShutdownName("db"))
+				app.AddShutdownHandler(func() error { return nil }, WithShutdownName("cache"), WithShutdownLevel(1))
+			},
+			Options:     []BootOptions{WithShutdownBudget(10 * time.Millisecond)},
+			ExpTimedOut: []string{"db"},
+			ExpSkipped:  []string{"cache"},
+			ExpErrs:     map[string]error{"db": ErrShutdownTimeout, "cache": ErrShutdownSkipped},
+		},
+	}
+
+	for _, t := range tests {
+		app := New()
+		t.SetupTest(app)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		app.fireShutdownHandlers(ctx, applyServOptions(t.Options...))
+		cancel()
+		app.Wait()
+
+		report := app.LastShutdownReport()
+		s.Require().ElementsMatch(t.ExpTimedOut, report.TimedOut(), t.Desc)
+		s.Require().ElementsMatch(t.ExpSkipped, report.Skipped(), t.Desc)
+		s.Require().Len(report.Results, len(t.ExpErrs), t.Desc)
+		for _, ret := range report.Results {
+			s.Require().Equal(t.ExpErrs[ret.Name], ret.Err, t.Desc)
+		}
+	}
+}
+
+func (s *bootSuite) TestSignalWatchersStopped() {
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		app := New()
+		ctx, cancel := context.WithCancel(context.Background())
+		app.fireShutdownHandlers(ctx, applyServOptions())
+		cancel()
+		app.Wait()
+	}
+
+	s.Require().Eventually(func() bool {
+		return runtime.NumGoroutine() <= before
+	}, time.Second, time.Millisecond)
+}
+
+func (s *bootSuite) TestRegister() {
+	s.app.RegisterServer("server-1", func(ShutdownFunc, ReadyFunc) error { return nil })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.app.Register(func(ShutdownFunc) error { return nil })
+		}()
+	}
+	wg.Wait()
+
+	s.Require().Len(s.app.serveHandlers, 21)
+	s.Require().NoError(validateServers(s.app.serveHandlers))
+}
+
+func (s *bootSuite) TestForceExit() {
+	exited := make(chan int, 1)
+	exit = func(code int) {
+		select {
+		case exited <- code:
+		default:
+		}
+	}
+
+	s.app.AddShutdownHandler(func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.app.fireShutdownHandlers(ctx, applyServOptions())
+	cancel()
+
+	// wait until shutdown begins
+	s.Require().Eventually(s.app.ShuttingDown, time.Second, time.Millisecond)
+	s.Require().NoError(syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+
+	select {
+	case code := <-exited:
+		s.Require().Equal(1, code)
+	case <-time.After(time.Second):
+		s.Fail("not exited")
+	}
+	s.app.Wait()
+}
+
+func (s *bootSuite) TestReadinessDrainDelay() {
+	tests := []struct {
+		Desc       string
+		Options    []BootOptions
+		Signal     bool
+		ExpDelayed time.Duration
+		ExpSkipped []string
+	}{
+		{
+			Desc:       "delayed on signal",
+			Options:    []BootOptions{WithReadinessDrainDelay(100 * time.Millisecond)},
+			Signal:     true,
+			ExpDelayed: 100 * time.Millisecond,
+			ExpSkipped: []string{},
+		},
+		{
+			Desc:       "not delayed on cancelled context",
+			Options:    []BootOptions{WithReadinessDrainDelay(time.Minute)},
+			ExpSkipped: []string{},
+		},
+		{
+			Desc:       "counted against the budget",
+			Options:    []BootOptions{WithReadinessDrainDelay(time.Minute), WithShutdownBudget(50 * time.Millisecond)},
+			Signal:     true,
+			ExpDelayed: 50 * time.Millisecond,
+			ExpSkipped: []string{"db"},
+		},
+	}
+
+	for _, t := range tests {
+		app := New()
+		var called atomic.Bool
+		app.AddShutdownHandler(func() error {
+			called.Store(true)
+			return nil
+		}, WithShutdownName("db"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		app.fireShutdownHandlers(ctx, applyServOptions(t.Options...))
+		now := time.Now()
+		if t.Signal {
+			s.Require().NoError(syscall.Kill(syscall.Getpid(), syscall.SIGTERM), t.Desc)
+			// readiness fails right away, handlers run after the delay
+			s.Require().Eventually(app.ShuttingDown, time.Second, time.Millisecond, t.Desc)
+			s.Require().False(called.Load(), t.Desc)
+		} else {
+			cancel()
+		}
+
+		done := make(chan struct{})
+		go func() {
+			app.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			s.FailNow("shutdown is blocked by the delay", t.Desc)
+		}
+		cancel()
+
+		s.Require().GreaterOrEqual(time.Since(now), t.ExpDelayed, t.Desc)
+		s.Require().ElementsMatch(t.ExpSkipped, app.LastShutdownReport().Skipped(), t.Desc)
+	}
+}
+
+func (s *bootSuite) TestRun() {
+	// boot two apps in the same process, and restart one of them.
+	run := func(ctx context.Context, app *App, started chan<- struct{}) error {
+		app.Register(func(shutdown ShutdownFunc) error {
+			pause := make(chan struct{})
+			shutdown(func() error {
+				close(pause)
+				return nil
+			}, WithShutdownName("server"))
+
+			close(started)
+			<-pause
+			return nil
+		})
+
+		return app.Run(ctx)
+	}
+
+	for i := 0; i < 2; i++ {
+		apps := []*App{New(), New()}
+		cancels := []context.CancelFunc{}
+		errCh := make(chan error, len(apps))
+		for _, app := range apps {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancels = append(cancels, cancel)
+
+			started := make(chan struct{})
+			go func(app *App) {
+				errCh <- run(ctx, app, started)
+			}(app)
+			<-started
+		}
+
+		// shutting down one app doesn't affect the other.
+		cancels[0]()
+		apps[0].Wait()
+		s.Require().True(apps[0].ShuttingDown())
+		s.Require().False(apps[1].ShuttingDown())
+		s.Require().Equal([]string{}, apps[0].LastShutdownReport().TimedOut())
+
+		cancels[1]()
+		apps[1].Wait()
+		s.Require().NoError(<-errCh)
+		s.Require().NoError(<-errCh)
+
+		s.Require().ErrorIs(apps[0].Run(context.Background()), ErrAlreadyRun)
+	}
+}
+
+func (s *bootSuite) TestRunServers() {
+	serve := func(name string, order chan<- string, readyAfter time.Duration) ServeFunc {
+		return func(shutdown ShutdownFunc, ready ReadyFunc) error {
+			pause := make(chan struct{})
+			shutdown(func() error {
+				close(pause)
+				return nil
+			}, WithShutdownName(name))
+
+			time.Sleep(readyAfter)
+			order <- name
+			ready()
+
+			<-pause
+			return nil
+		}
+	}
+
+	tests := []struct {
+		Desc      string
+		SetupTest func(*App, chan<- string)
+		Options   []BootOptions
+		ExpErr    error
+		ExpErrStr string
+		ExpOrder  []string
+	}{
+		{
+			Desc: "start in dependency order",
+			SetupTest: func(app *App, order chan<- string) {
+				app.RegisterServer("gateway", serve("gateway", order, 0), WithDependsOn("grpc"))
+				app.RegisterServer("grpc", serve("grpc", order, 50*time.Millisecond), WithDependsOn("db"))
+				app.RegisterServer("db", serve("db", order, 50*time.Millisecond))
+			},
+			ExpOrder: []string{"db", "grpc", "gateway"},
+		},
+		{
+			Desc: "server failed",
+			SetupTest: func(app *App, order chan<- string) {
+				app.RegisterServer("grpc", func(ShutdownFunc, ReadyFunc) error { return errors.New("address already in use") })
+			},
+			ExpErrStr: "grpc: address already in use",
+		},
+		{
+			Desc: "server not ready",
+			SetupTest: func(app *App, order chan<- string) {
+				app.RegisterServer("grpc", serve("grpc", order, time.Second))
+				app.RegisterServer("gateway", serve("gateway", order, 0), WithDependsOn("grpc"))
+			},
+			Options:   []BootOptions{WithStartupTimeout(50 * time.Millisecond)},
+			ExpErr:    ErrStartupTimeout,
+			ExpErrStr: "startup timed out: grpc, gateway",
+		},
+		{
+			Desc: "duplicated server",
+			SetupTest: func(app *App, order chan<- string) {
+				app.RegisterServer("grpc", serve("grpc", order, 0))
+				app.RegisterServer("grpc", serve("grpc", order, 0))
+			},
+			ExpErr: ErrDuplicatedServer,
+		},
+		{
+			Desc: "unknown dependency",
+			SetupTest: func(app *App, order chan<- string) {
+				app.RegisterServer("gateway", serve("gateway", order, 0), WithDependsOn("grpc"))
+			},
+			ExpErr: ErrUnknownDependency,
+		},
+		{
+			Desc: "cyclic dependency",
+			SetupTest: func(app *App, order chan<- string) {
+				app.RegisterServer("grpc", serve("grpc", order, 0), WithDependsOn("gateway"))
+				app.RegisterServer("gateway", serve("gateway", order, 0), WithDependsOn("grpc"))
+			},
+			ExpErr: ErrCyclicDependency,
+		},
+	}
+
+	for _, t := range tests {
+		app := New()
+		order := make(chan string, 10)
+		t.SetupTest(app, order)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := app.Run(ctx, t.Options...)
+		if t.ExpErr != nil || t.ExpErrStr != "" {
+			s.Require().Error(err, t.Desc)
+			if t.ExpErr != nil {
+				s.Require().ErrorIs(err, t.ExpErr, t.Desc)
+			}
+			if t.ExpErrStr != "" {
+				s.Require().Equal(t.ExpErrStr, err.Error(), t.Desc)
+			}
+			cancel()
+			continue
+		}
+		s.Require().NoError(err, t.Desc)
+
+		close(order)
+		got := []string{}
+		for name := range order {
+			got = append(got, name)
+		}
+		s.Require().Equal(t.ExpOrder, got, t.Desc)
+
+		cancel()
+		app.Wait()
+	}
+}
+
+func (s *bootSuite) TestShutdownInDependencyOrder() {
+	serve := func(name string) ServeFunc {
+		return func(shutdown ShutdownFunc, ready ReadyFunc) error {
+			pause := make(chan struct{})
+			shutdown(func() error {
+				close(pause)
+				return nil
+			}, WithShutdownName(name))
+
+			ready()
+			<-pause
+			return nil
+		}
+	}
+
+	app := New()
+	app.RegisterServer("gateway", serve("gateway"), WithDependsOn("grpc"))
+	app.RegisterServer("grpc", serve("grpc"), WithDependsOn("db"))
+	app.RegisterServer("db", serve("db"))
+	app.RegisterServer("metrics", serve("metrics"))
+	app.AddShutdownHandler(func() error { return nil }, WithShutdownName("flush"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(app.Run(ctx))
+	cancel()
+	app.Wait()
+
+	levels := map[string]int{}
+	for _, ret := range app.LastShutdownReport().Results {
+		levels[ret.Name] = ret.Level
+	}
+	s.Require().Equal(map[string]int{"gateway": -3, "grpc": -2, "db": -1, "metrics": -1, "flush": 0}, levels)
+}
+
+func (s *bootSuite) TestRestartPolicy() {
+	// flaky fails until the nth run, then serves until shutdown.
+	flaky := func(runs *atomic.Int32, failures int32, ret error) ServeFunc {
+		return func(shutdown ShutdownFunc, ready ReadyFunc) error {
+			if runs.Add(1) <= failures {
+				return ret
+			}
+
+			pause := make(chan struct{})
+			shutdown(func() error {
+				close(pause)
+				return nil
+			}, WithShutdownName("worker"))
+
+			ready()
+			<-pause
+			return nil
+		}
+	}
+	backoff := WithRestartBackoff(time.Millisecond, 5*time.Millisecond)
+
+	tests := []struct {
+		Desc      string
+		Failures  int32
+		Ret       error
+		Options   []RegisterOptions
+		ExpErr    error
+		ExpRuns   int32
+		ExpHlrNum int
+	}{
+		{
+			Desc:     "never restart",
+			Failures: 1,
+			Ret:      errors.New("connection reset"),
+			ExpErr:   errors.New("worker: connection reset"),
+			ExpRuns:  1,
+		},
+		{
+			Desc:      "restart on failure",
+			Failures:  3,
+			Ret:       errors.New("connection reset"),
+			Options:   []RegisterOptions{WithRestartPolicy(RestartPolicyOnFailure), backoff},
+			ExpRuns:   4,
+			ExpHlrNum: 1,
+		},
+		{
+			Desc:     "restart budget exceeded",
+			Failures: 3,
+			Ret:      errors.New("connection reset"),
+			Options:  []RegisterOptions{WithRestartPolicy(RestartPolicyOnFailure), backoff, WithMaxRestarts(2, time.Minute)},
+			ExpErr:   errors.New("worker: restart budget exceeded: connection reset"),
+			ExpRuns:  3,
+		},
+		{
+			Desc:      "always restart",
+			Failures:  2,
+			Ret:       nil,
+			Options:   []RegisterOptions{WithRestartPolicy(RestartPolicyAlways), backoff},
+			ExpRuns:   3,
+			ExpHlrNum: 1,
+		},
+	}
+
+	for _, t := range tests {
+		app := New()
+		runs := &atomic.Int32{}
+		app.RegisterServer("worker", flaky(runs, t.Failures, t.Ret), t.Options...)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := app.Run(ctx)
+		if t.ExpErr != nil {
+			s.Require().EqualError(err, t.ExpErr.Error(), t.Desc)
+		} else {
+			s.Require().NoError(err, t.Desc)
+		}
+		s.Require().Equal(t.ExpRuns, runs.Load(), t.Desc)
+
+		cancel()
+		app.Wait()
+		s.Require().Len(app.LastShutdownReport().Results, t.ExpHlrNum, t.Desc)
+	}
+}
+
+func (s *bootSuite) TestReload() {
+	calls := make(chan string, 10)
+	s.app.AddReloadHandler("log-level", func(context.Context) error {
+		calls <- "log-level"
+		return errors.New("invalid level")
+	})
+	s.app.AddReloadHandler("cors", func(context.Context) error {
+		calls <- "cors"
+		panic("nil map")
+	})
+	s.app.AddReloadHandler("feature-flags", func(context.Context) error {
+		calls <- "feature-flags"
+		return nil
+	})
+
+	// a failed handler doesn't stop the others.
+	err := s.app.Reload(context.Background())
+	s.Require().EqualError(err, "log-level: invalid level\ncors: reload handler panicked: nil map")
+	s.Require().ErrorIs(err, ErrReloadPanic)
+	s.Require().Equal("log-level", <-calls)
+	s.Require().Equal("cors", <-calls)
+	s.Require().Equal("feature-flags", <-calls)
+
+	// SIGHUP triggers reload once the app runs.
+	s.app.Register(func(shutdown ShutdownFunc) error {
+		pause := make(chan struct{})
+		shutdown(func() error {
+			close(pause)
+			return nil
+		})
+		<-pause
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(s.app.Run(ctx))
+	s.Require().NoError(syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+
+	for _, exp := range []string{"log-level", "cors", "feature-flags"} {
+		select {
+		case name := <-calls:
+			s.Require().Equal(exp, name)
+		case <-time.After(time.Second):
+			s.Fail("not reloaded")
+		}
+	}
+
+	cancel()
+	s.app.Wait()
+}
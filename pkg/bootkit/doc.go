@@ -1,4 +1,15 @@
 // Package bootkit plays as a role of manager coordinating booting up or shutting down the system.
 // It provides convenient interface for working with os.Signal.
+// Servers registered with RegisterServer() signal readiness explicitly and can depend on each other,
+// ListenAndServe() returns once all of them are ready. On shutdown, servers stop before the ones they depend on.
+// A server can be restarted in place with WithRestartPolicy(), otherwise the app shuts down once it fails.
 // Multiple hooks with level can be applied, they will be called simultaneously with the same level on app shutdown.
+// Handlers can be bounded by timeouts per handler, per level and for the whole shutdown process,
+// and another terminational signal during shutdown forces an immediate exit.
+// Handlers added by AddReloadHandler() are called one by one on SIGHUP to re-read configuration.
+// Components can also register probes with AddProbe(), which are aggregated into readiness and liveness reports.
+// Lifecycle events of servers and shutdown handlers are logged and kept in order, see Lifecycle().
+//
+// The package-level functions work with a default App. Create another one with New() to run
+// multiple sets of servers in the same process, ex: booting and tearing down servers repeatedly in tests.
 package bootkit
@@ -0,0 +1,185 @@
+//go:generate go-enum -f=$GOFILE --nocase --marshal
+
+package bootkit
+
+import (
+	"fmt"
+	"time"
+
+	"demo/pkg/logger"
+)
+
+// EventType is an enumeration of lifecycle events recorded by an App.
+/*
+ENUM(
+registered // A server is registered.
+starting // A server is launched, or relaunched after a restart.
+ready // A server signals it's ready to serve.
+failed // A server returns an error.
+stopped // A server returns without error.
+restarting // A server is going to restart based on its RestartPolicy.
+started // All servers are ready.
+shutdown-started // The app receives a terminational signal or its context is done.
+level-started // Shutdown handlers at a level are triggered.
+handler-finished // A shutdown handler returns, times out or is skipped.
+shutdown-finished // All shutdown handlers finished.
+)
+*/
+type EventType int32
+
+const (
+	// LifecycleStateIdle means the app hasn't run yet.
+	LifecycleStateIdle = "idle"
+	// LifecycleStateStarting means servers are starting.
+	LifecycleStateStarting = "starting"
+	// LifecycleStateRunning means all servers were ready.
+	LifecycleStateRunning = "running"
+	// LifecycleStateShuttingDown means shutdown handlers are running.
+	LifecycleStateShuttingDown = "shutting-down"
+	// LifecycleStateStopped means all shutdown handlers finished.
+	LifecycleStateStopped = "stopped"
+
+	// maxLifecycleEvents bounds the recorded events, the oldest ones are dropped.
+	maxLifecycleEvents = 1000
+)
+
+// Event is a lifecycle event of an App.
+type Event struct {
+	Type     EventType `json:"type"`
+	Time     time.Time `json:"time"`
+	Server   string    `json:"server,omitempty"`
+	Handler  string    `json:"handler,omitempty"`
+	Level    *int      `json:"level,omitempty"`
+	Restarts int       `json:"restarts,omitempty"`
+	Duration string    `json:"duration,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// ServerStatus is the latest state of a registered server.
+type ServerStatus struct {
+	Name     string    `json:"name"`
+	Status   EventType `json:"status"`
+	Restarts int       `json:"restarts"`
+}
+
+// LifecycleReport is a snapshot of the lifecycle of an App.
+type LifecycleReport struct {
+	State           string         `json:"state"`
+	StartupDuration string         `json:"startupDuration,omitempty"`
+	ShutdownLevel   *int           `json:"shutdownLevel,omitempty"`
+	Servers         []ServerStatus `json:"servers"`
+	Events          []Event        `json:"events"`
+}
+
+type lifecycle struct {
+	state           string
+	startupDuration string
+	shutdownLevel   *int
+	servers         []*ServerStatus
+	events          []Event
+}
+
+// Lifecycle returns the current state of the app, its servers and the recorded events in order.
+func (a *App) Lifecycle() LifecycleReport {
+	a.lifecycleMux.RLock()
+	defer a.lifecycleMux.RUnlock()
+
+	lc := a.lifecycle
+	report := LifecycleReport{
+		State:           lc.state,
+		StartupDuration: lc.startupDuration,
+		Servers:         make([]ServerStatus, len(lc.servers)),
+		Events:          make([]Event, len(lc.events)),
+	}
+	if lc.shutdownLevel != nil {
+		l := *lc.shutdownLevel
+		report.ShutdownLevel = &l
+	}
+	for i, s := range lc.servers {
+		report.Servers[i] = *s
+	}
+	copy(report.Events, lc.events)
+
+	return report
+}
+
+// record keeps the event, and logs it with the same field names as the JSON ones.
+func (a *App) record(ev Event, err error) {
+	ev.Time = time.Now()
+	if err != nil {
+		ev.Error = err.Error()
+	}
+
+	a.lifecycleMux.Lock()
+	lc := &a.lifecycle
+	switch ev.Type {
+	case EventTypeRegistered:
+		lc.servers = append(lc.servers, &ServerStatus{Name: ev.Server, Status: ev.Type})
+	case EventTypeStarting:
+		if lc.state == LifecycleStateIdle {
+			lc.state = LifecycleStateStarting
+		}
+	case EventTypeStarted:
+		lc.state = LifecycleStateRunning
+		lc.startupDuration = ev.Duration
+	case EventTypeShutdownStarted:
+		lc.state = LifecycleStateShuttingDown
+	case EventTypeLevelStarted:
+		lc.shutdownLevel = ev.Level
+	case EventTypeShutdownFinished:
+		lc.state = LifecycleStateStopped
+		lc.shutdownLevel = nil
+	}
+	if ev.Server != "" {
+		for _, s := range lc.servers {
+			if s.Name == ev.Server {
+				s.Status = ev.Type
+				s.Restarts = ev.Restarts
+				break
+			}
+		}
+	}
+	lc.events = append(lc.events, ev)
+	if len(lc.events) > maxLifecycleEvents {
+		lc.events = lc.events[len(lc.events)-maxLifecycleEvents:]
+	}
+	a.lifecycleMux.Unlock()
+
+	fs := logger.Fields{"event": ev.Type.String()}
+	msg := ev.Type.String()
+	if ev.Server != "" {
+		fs["server"] = ev.Server
+		msg = fmt.Sprintf("%s: %s", msg, ev.Server)
+	}
+	if ev.Handler != "" {
+		fs["handler"] = ev.Handler
+		msg = fmt.Sprintf("%s: %s", msg, ev.Handler)
+	}
+	if ev.Level != nil {
+		fs["level"] = *ev.Level
+	}
+	if ev.Restarts > 0 {
+		fs["restarts"] = ev.Restarts
+	}
+	if ev.Duration != "" {
+		fs["duration"] = ev.Duration
+	}
+	if ev.Reason != "" {
+		fs["reason"] = ev.Reason
+	}
+
+	switch {
+	case err != nil && ev.Type == EventTypeRestarting:
+		logger.Warn(msg, logger.WithError(err), logger.WithFields(fs))
+	case err != nil:
+		logger.Error(msg, logger.WithError(err), logger.WithFields(fs))
+	default:
+		logger.Info(msg, logger.WithFields(fs))
+	}
+}
+
+// Lifecycle returns the current state of the default App.
+func Lifecycle() LifecycleReport {
+	return defaultApp.Lifecycle()
+}
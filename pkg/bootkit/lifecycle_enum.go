@@ -0,0 +1,128 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package bootkit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// EventTypeRegistered is a EventType of type Registered.
+	// A server is registered.
+	EventTypeRegistered EventType = iota
+	// EventTypeStarting is a EventType of type Starting.
+	// A server is launched, or relaunched after a restart.
+	EventTypeStarting
+	// EventTypeReady is a EventType of type Ready.
+	// A server signals it's ready to serve.
+	EventTypeReady
+	// EventTypeFailed is a EventType of type Failed.
+	// A server returns an error.
+	EventTypeFailed
+	// EventTypeStopped is a EventType of type Stopped.
+	// A server returns without error.
+	EventTypeStopped
+	// EventTypeRestarting is a EventType of type Restarting.
+	// A server is going to restart based on its RestartPolicy.
+	EventTypeRestarting
+	// EventTypeStarted is a EventType of type Started.
+	// All servers are ready.
+	EventTypeStarted
+	// EventTypeShutdownStarted is a EventType of type Shutdown-Started.
+	// The app receives a terminational signal or its context is done.
+	EventTypeShutdownStarted
+	// EventTypeLevelStarted is a EventType of type Level-Started.
+	// Shutdown handlers at a level are triggered.
+	EventTypeLevelStarted
+	// EventTypeHandlerFinished is a EventType of type Handler-Finished.
+	// A shutdown handler returns, times out or is skipped.
+	EventTypeHandlerFinished
+	// EventTypeShutdownFinished is a EventType of type Shutdown-Finished.
+	// All shutdown handlers finished.
+	EventTypeShutdownFinished
+)
+
+var ErrInvalidEventType = errors.New("not a valid EventType")
+
+const _EventTypeName = "registeredstartingreadyfailedstoppedrestartingstartedshutdown-startedlevel-startedhandler-finishedshutdown-finished"
+
+var _EventTypeMap = map[EventType]string{
+	EventTypeRegistered:       _EventTypeName[0:10],
+	EventTypeStarting:         _EventTypeName[10:18],
+	EventTypeReady:            _EventTypeName[18:23],
+	EventTypeFailed:           _EventTypeName[23:29],
+	EventTypeStopped:          _EventTypeName[29:36],
+	EventTypeRestarting:       _EventTypeName[36:46],
+	EventTypeStarted:          _EventTypeName[46:53],
+	EventTypeShutdownStarted:  _EventTypeName[53:69],
+	EventTypeLevelStarted:     _EventTypeName[69:82],
+	EventTypeHandlerFinished:  _EventTypeName[82:98],
+	EventTypeShutdownFinished: _EventTypeName[98:115],
+}
+
+// String implements the Stringer interface.
+func (x EventType) String() string {
+	if str, ok := _EventTypeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("EventType(%d)", x)
+}
+
+var _EventTypeValue = map[string]EventType{
+	_EventTypeName[0:10]:                    EventTypeRegistered,
+	strings.ToLower(_EventTypeName[0:10]):   EventTypeRegistered,
+	_EventTypeName[10:18]:                   EventTypeStarting,
+	strings.ToLower(_EventTypeName[10:18]):  EventTypeStarting,
+	_EventTypeName[18:23]:                   EventTypeReady,
+	strings.ToLower(_EventTypeName[18:23]):  EventTypeReady,
+	_EventTypeName[23:29]:                   EventTypeFailed,
+	strings.ToLower(_EventTypeName[23:29]):  EventTypeFailed,
+	_EventTypeName[29:36]:                   EventTypeStopped,
+	strings.ToLower(_EventTypeName[29:36]):  EventTypeStopped,
+	_EventTypeName[36:46]:                   EventTypeRestarting,
+	strings.ToLower(_EventTypeName[36:46]):  EventTypeRestarting,
+	_EventTypeName[46:53]:                   EventTypeStarted,
+	strings.ToLower(_EventTypeName[46:53]):  EventTypeStarted,
+	_EventTypeName[53:69]:                   EventTypeShutdownStarted,
+	strings.ToLower(_EventTypeName[53:69]):  EventTypeShutdownStarted,
+	_EventTypeName[69:82]:                   EventTypeLevelStarted,
+	strings.ToLower(_EventTypeName[69:82]):  EventTypeLevelStarted,
+	_EventTypeName[82:98]:                   EventTypeHandlerFinished,
+	strings.ToLower(_EventTypeName[82:98]):  EventTypeHandlerFinished,
+	_EventTypeName[98:115]:                  EventTypeShutdownFinished,
+	strings.ToLower(_EventTypeName[98:115]): EventTypeShutdownFinished,
+}
+
+// ParseEventType attempts to convert a string to a EventType.
+func ParseEventType(name string) (EventType, error) {
+	if x, ok := _EventTypeValue[name]; ok {
+		return x, nil
+	}
+	// Case insensitive parse, do a separate lookup to prevent unnecessary cost of lowercasing a string if we don't need to.
+	if x, ok := _EventTypeValue[strings.ToLower(name)]; ok {
+		return x, nil
+	}
+	return EventType(0), fmt.Errorf("%s is %w", name, ErrInvalidEventType)
+}
+
+// MarshalText implements the text marshaller method.
+func (x EventType) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *EventType) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseEventType(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
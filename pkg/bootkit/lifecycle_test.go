@@ -0,0 +1,84 @@
+package bootkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type lifecycleSuite struct {
+	suite.Suite
+
+	app *App
+}
+
+func (s *lifecycleSuite) SetupSuite()    {}
+func (s *lifecycleSuite) TearDownSuite() {}
+func (s *lifecycleSuite) SetupTest()     { s.app = New() }
+func (s *lifecycleSuite) TearDownTest()  {}
+
+func TestLifecycleSuite(t *testing.T) {
+	suite.Run(t, new(lifecycleSuite))
+}
+
+func (s *lifecycleSuite) TestLifecycle() {
+	s.Require().Equal(LifecycleStateIdle, s.app.Lifecycle().State)
+
+	s.app.AddShutdownHandler(func() error { return errors.New("close failed") }, WithShutdownName("db"), WithShutdownLevel(1))
+	s.app.RegisterServer("grpc", func(shutdown ShutdownFunc, ready ReadyFunc) error {
+		pause := make(chan struct{})
+		shutdown(func() error {
+			close(pause)
+			return nil
+		}, WithShutdownName("grpc"))
+
+		ready()
+		<-pause
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(s.app.Run(ctx))
+
+	report := s.app.Lifecycle()
+	s.Require().Equal(LifecycleStateRunning, report.State)
+	s.Require().NotEmpty(report.StartupDuration)
+	s.Require().Nil(report.ShutdownLevel)
+	s.Require().Equal([]ServerStatus{{Name: "grpc", Status: EventTypeReady}}, report.Servers)
+
+	cancel()
+	s.app.Wait()
+	// the server returns after its shutdown handler
+	time.Sleep(10 * time.Millisecond)
+
+	report = s.app.Lifecycle()
+	s.Require().Equal(LifecycleStateStopped, report.State)
+	s.Require().Equal([]ServerStatus{{Name: "grpc", Status: EventTypeStopped}}, report.Servers)
+
+	types := []EventType{}
+	for _, ev := range report.Events {
+		// the server stops concurrently with the following shutdown handlers
+		if ev.Type != EventTypeStopped {
+			types = append(types, ev.Type)
+		}
+		if ev.Type == EventTypeHandlerFinished && ev.Handler == "db" {
+			s.Require().Equal(1, *ev.Level)
+			s.Require().Equal("close failed", ev.Error)
+		}
+	}
+	s.Require().Equal([]EventType{
+		EventTypeRegistered,
+		EventTypeStarting,
+		EventTypeReady,
+		EventTypeStarted,
+		EventTypeShutdownStarted,
+		EventTypeLevelStarted,
+		EventTypeHandlerFinished,
+		EventTypeLevelStarted,
+		EventTypeHandlerFinished,
+		EventTypeShutdownFinished,
+	}, types)
+}
@@ -1,12 +1,21 @@
 package bootkit
 
+import "time"
+
 // BootOptions sets the options.
 type BootOptions interface {
 	apply(*bootOptions)
 }
 
 type bootOptions struct {
-	level int
+	level   int
+	name    string
+	timeout time.Duration
+
+	budget         time.Duration
+	levelTimeouts  map[int]time.Duration
+	startupTimeout time.Duration
+	drainDelay     time.Duration
 }
 
 // EmptyBootOption does not alter the server configuration. It can be embedded
@@ -43,8 +52,63 @@ func WithShutdownLevel(level uint) BootOptions {
 	return withShutdownLevel(int(level))
 }
 
-func withTopPriorityShutdownLevel() BootOptions {
-	return withShutdownLevel(topPriorityShutdownLevel)
+// WithShutdownName specifies the name of the shutdown handler used in logs and ShutdownReport.
+func WithShutdownName(name string) BootOptions {
+	return newFuncBootOption(func(opts *bootOptions) {
+		opts.name = name
+	})
+}
+
+// WithShutdownTimeout specifies how long the shutdown handler can run.
+// The handler is reported as timed out and the shutdown process moves on once the timeout passes.
+// Zero means no timeout, which is the default.
+func WithShutdownTimeout(timeout time.Duration) BootOptions {
+	return newFuncBootOption(func(opts *bootOptions) {
+		opts.timeout = timeout
+	})
+}
+
+// WithShutdownLevelTimeout specifies how long all handlers at the same level can run in `ListenAndServe()`.
+// i.e.,
+// ListenAndServe(ctx, WithShutdownLevelTimeout(1, 5*time.Second))
+// handlers with WithShutdownLevel(1) are reported as timed out if they don't finish within 5 seconds.
+// Servers shut down before handlers at negative levels, -1 for servers depending on none of the others,
+// -2 for the ones depending on them and so on, e.g., WithShutdownLevelTimeout(-1, 5*time.Second).
+func WithShutdownLevelTimeout(level int, timeout time.Duration) BootOptions {
+	return newFuncBootOption(func(opts *bootOptions) {
+		if opts.levelTimeouts == nil {
+			opts.levelTimeouts = map[int]time.Duration{}
+		}
+		opts.levelTimeouts[level] = timeout
+	})
+}
+
+// WithShutdownBudget specifies the overall duration of the shutdown process in `ListenAndServe()`.
+// Levels which haven't started once the budget is used up are skipped.
+// It's 25 seconds by default, and zero means no budget.
+func WithShutdownBudget(budget time.Duration) BootOptions {
+	return newFuncBootOption(func(opts *bootOptions) {
+		opts.budget = budget
+	})
+}
+
+// WithReadinessDrainDelay specifies how long `ListenAndServe()` waits between failing readiness probes
+// and running the first level of shutdown handlers on a terminational signal, so k8s stops routing traffic
+// to the pod before servers close. The delay counts against the shutdown budget, keep it well below.
+// It's 3 seconds by default, and zero means no delay.
+// Shutdown caused by a cancelled context or a failed server starts right away.
+func WithReadinessDrainDelay(delay time.Duration) BootOptions {
+	return newFuncBootOption(func(opts *bootOptions) {
+		opts.drainDelay = delay
+	})
+}
+
+// WithStartupTimeout specifies how long `ListenAndServe()` waits for all servers to be ready.
+// It's 30 seconds by default.
+func WithStartupTimeout(timeout time.Duration) BootOptions {
+	return newFuncBootOption(func(opts *bootOptions) {
+		opts.startupTimeout = timeout
+	})
 }
 
 func withShutdownLevel(level int) BootOptions {
@@ -54,7 +118,162 @@ func withShutdownLevel(level int) BootOptions {
 }
 
 func applyServOptions(options ...BootOptions) *bootOptions {
-	opts := &bootOptions{}
+	opts := &bootOptions{
+		budget:         defaultShutdownBudget,
+		startupTimeout: defaultStartupTimeout,
+		drainDelay:     defaultReadinessDrainDelay,
+	}
+	for _, o := range options {
+		o.apply(opts)
+	}
+
+	return opts
+}
+
+// RegisterOptions sets the options of registered servers.
+type RegisterOptions interface {
+	apply(*registerOptions)
+}
+
+type registerOptions struct {
+	dependsOn []string
+
+	policy      RestartPolicy
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	maxRestarts int
+	window      time.Duration
+}
+
+// funcRegisterOption wraps a function that modifies registerOptions into an
+// implementation of the RegisterOptions interface.
+type funcRegisterOption struct {
+	f func(*registerOptions)
+}
+
+func (o *funcRegisterOption) apply(do *registerOptions) {
+	o.f(do)
+}
+
+func newFuncRegisterOption(f func(*registerOptions)) *funcRegisterOption {
+	return &funcRegisterOption{
+		f: f,
+	}
+}
+
+// WithDependsOn specifies names of servers which should be ready before the server starts.
+// i.e.,
+// RegisterServer("grpc-gateway", runGateway, WithDependsOn("grpc-server"))
+// runGateway is launched after the server named "grpc-server" calls ready().
+func WithDependsOn(names ...string) RegisterOptions {
+	return newFuncRegisterOption(func(opts *registerOptions) {
+		opts.dependsOn = append(opts.dependsOn, names...)
+	})
+}
+
+// WithRestartPolicy specifies whether the server is restarted in place once it returns.
+// It's RestartPolicyNever by default, i.e., the app shuts down once the server fails.
+// Keep critical servers like the gRPC listener with RestartPolicyNever to fail fast.
+func WithRestartPolicy(policy RestartPolicy) RegisterOptions {
+	return newFuncRegisterOption(func(opts *registerOptions) {
+		opts.policy = policy
+	})
+}
+
+// WithRestartBackoff specifies the exponential backoff between restarts.
+// The first restart waits for `initial`, and the following ones double it until `max`.
+// It's from 1 second to 30 seconds by default.
+func WithRestartBackoff(initial, max time.Duration) RegisterOptions {
+	return newFuncRegisterOption(func(opts *registerOptions) {
+		opts.backoff = initial
+		opts.maxBackoff = max
+	})
+}
+
+// WithMaxRestarts specifies how many times the server can restart within the window.
+// The app shuts down once the budget is used up. It's 5 times within 1 minute by default.
+func WithMaxRestarts(max int, window time.Duration) RegisterOptions {
+	return newFuncRegisterOption(func(opts *registerOptions) {
+		opts.maxRestarts = max
+		opts.window = window
+	})
+}
+
+func applyRegisterOptions(options ...RegisterOptions) *registerOptions {
+	opts := &registerOptions{
+		policy:      RestartPolicyNever,
+		backoff:     defaultRestartBackoff,
+		maxBackoff:  defaultRestartMaxBackoff,
+		maxRestarts: defaultMaxRestarts,
+		window:      defaultRestartWindow,
+	}
+	for _, o := range options {
+		o.apply(opts)
+	}
+
+	return opts
+}
+
+// ProbeOptions sets the probe options.
+type ProbeOptions interface {
+	apply(*probeOptions)
+}
+
+type probeOptions struct {
+	timeout  time.Duration
+	critical bool
+	kinds    ProbeKind
+}
+
+// funcProbeOption wraps a function that modifies probeOptions into an
+// implementation of the ProbeOptions interface.
+type funcProbeOption struct {
+	f func(*probeOptions)
+}
+
+func (o *funcProbeOption) apply(do *probeOptions) {
+	o.f(do)
+}
+
+func newFuncProbeOption(f func(*probeOptions)) *funcProbeOption {
+	return &funcProbeOption{
+		f: f,
+	}
+}
+
+// WithProbeTimeout specifies how long a probe can run before it's regarded as failed.
+func WithProbeTimeout(timeout time.Duration) ProbeOptions {
+	return newFuncProbeOption(func(opts *probeOptions) {
+		opts.timeout = timeout
+	})
+}
+
+// WithProbeCritical specifies whether a failed probe fails the aggregated result.
+// A failed non-critical probe only degrades the aggregated result.
+func WithProbeCritical(critical bool) ProbeOptions {
+	return newFuncProbeOption(func(opts *probeOptions) {
+		opts.critical = critical
+	})
+}
+
+// WithProbeKinds specifies the aggregated endpoints the probe contributes to.
+// i.e.,
+// AddProbe("mysql", pingDB, WithProbeKinds(ProbeReadiness, ProbeLiveness))
+func WithProbeKinds(kinds ...ProbeKind) ProbeOptions {
+	return newFuncProbeOption(func(opts *probeOptions) {
+		opts.kinds = 0
+		for _, k := range kinds {
+			opts.kinds |= k
+		}
+	})
+}
+
+func applyProbeOptions(options ...ProbeOptions) *probeOptions {
+	opts := &probeOptions{
+		timeout:  defaultProbeTimeout,
+		critical: true,
+		kinds:    ProbeReadiness,
+	}
 	for _, o := range options {
 		o.apply(opts)
 	}
@@ -0,0 +1,13 @@
+//go:generate go-enum -f=$GOFILE --nocase
+
+package bootkit
+
+// RestartPolicy is an enumeration of supervision policies of registered servers.
+/*
+ENUM(
+Never // Never restarts the server, the app shuts down once it fails. It's the default policy.
+OnFailure // Restarts the server only if it returns an error.
+Always // Restarts the server whenever it returns unless the app is shutting down.
+)
+*/
+type RestartPolicy int32
@@ -0,0 +1,64 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package bootkit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// RestartPolicyNever is a RestartPolicy of type Never.
+	// Never restarts the server, the app shuts down once it fails. It's the default policy.
+	RestartPolicyNever RestartPolicy = iota
+	// RestartPolicyOnFailure is a RestartPolicy of type OnFailure.
+	// Restarts the server only if it returns an error.
+	RestartPolicyOnFailure
+	// RestartPolicyAlways is a RestartPolicy of type Always.
+	// Restarts the server whenever it returns unless the app is shutting down.
+	RestartPolicyAlways
+)
+
+var ErrInvalidRestartPolicy = errors.New("not a valid RestartPolicy")
+
+const _RestartPolicyName = "NeverOnFailureAlways"
+
+var _RestartPolicyMap = map[RestartPolicy]string{
+	RestartPolicyNever:     _RestartPolicyName[0:5],
+	RestartPolicyOnFailure: _RestartPolicyName[5:14],
+	RestartPolicyAlways:    _RestartPolicyName[14:20],
+}
+
+// String implements the Stringer interface.
+func (x RestartPolicy) String() string {
+	if str, ok := _RestartPolicyMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("RestartPolicy(%d)", x)
+}
+
+var _RestartPolicyValue = map[string]RestartPolicy{
+	_RestartPolicyName[0:5]:                    RestartPolicyNever,
+	strings.ToLower(_RestartPolicyName[0:5]):   RestartPolicyNever,
+	_RestartPolicyName[5:14]:                   RestartPolicyOnFailure,
+	strings.ToLower(_RestartPolicyName[5:14]):  RestartPolicyOnFailure,
+	_RestartPolicyName[14:20]:                  RestartPolicyAlways,
+	strings.ToLower(_RestartPolicyName[14:20]): RestartPolicyAlways,
+}
+
+// ParseRestartPolicy attempts to convert a string to a RestartPolicy.
+func ParseRestartPolicy(name string) (RestartPolicy, error) {
+	if x, ok := _RestartPolicyValue[name]; ok {
+		return x, nil
+	}
+	// Case insensitive parse, do a separate lookup to prevent unnecessary cost of lowercasing a string if we don't need to.
+	if x, ok := _RestartPolicyValue[strings.ToLower(name)]; ok {
+		return x, nil
+	}
+	return RestartPolicy(0), fmt.Errorf("%s is %w", name, ErrInvalidRestartPolicy)
+}
@@ -0,0 +1,212 @@
+package bootkit
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProbeFunc checks the health of a component. A nil error means healthy.
+type ProbeFunc func(ctx context.Context) error
+
+// ProbeKind indicates which aggregated endpoint a probe contributes to.
+type ProbeKind int
+
+const (
+	// ProbeReadiness means the probe decides whether the app is able to accept traffic.
+	ProbeReadiness ProbeKind = 1 << iota
+	// ProbeLiveness means the probe decides whether the app should be restarted.
+	ProbeLiveness
+)
+
+const (
+	// ProbeStatusOK means all critical checks passed.
+	ProbeStatusOK = "ok"
+	// ProbeStatusDegraded means all critical checks passed but some non-critical ones failed.
+	ProbeStatusDegraded = "degraded"
+	// ProbeStatusFailed means at least one critical check failed.
+	ProbeStatusFailed = "failed"
+
+	defaultProbeTimeout = time.Second
+)
+
+var (
+	// ErrShuttingDown indicates the app received a terminational signal and is draining.
+	ErrShuttingDown = errors.New("shutting down")
+)
+
+type probe struct {
+	name     string
+	check    ProbeFunc
+	timeout  time.Duration
+	critical bool
+	kinds    ProbeKind
+}
+
+// ProbeResult is the outcome of a single check.
+type ProbeResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ProbeReport aggregates the results of all checks with the same kind.
+type ProbeReport struct {
+	Status string        `json:"status"`
+	Reason string        `json:"reason,omitempty"`
+	Checks []ProbeResult `json:"checks"`
+}
+
+// Healthy returns true unless a critical check failed.
+func (r ProbeReport) Healthy() bool {
+	return r.Status != ProbeStatusFailed
+}
+
+// AddProbe registers a named check used by CheckReadiness() and CheckLiveness().
+// By default, a probe contributes to readiness only, is critical, and times out after one second.
+// Registering a probe with an existing name replaces the previous one.
+func (a *App) AddProbe(name string, check ProbeFunc, options ...ProbeOptions) {
+	o := applyProbeOptions(options...)
+
+	a.probeMux.Lock()
+	defer a.probeMux.Unlock()
+
+	p := &probe{
+		name:     name,
+		check:    check,
+		timeout:  o.timeout,
+		critical: o.critical,
+		kinds:    o.kinds,
+	}
+
+	for i := range a.probes {
+		if a.probes[i].name == name {
+			a.probes[i] = p
+			return
+		}
+	}
+
+	a.probes = append(a.probes, p)
+}
+
+// ShuttingDown returns true once the app starts shutting down.
+func (a *App) ShuttingDown() bool {
+	return a.shuttingDown.Load()
+}
+
+// CheckReadiness runs all readiness probes concurrently.
+// It fails immediately once the app starts shutting down so that traffic can be drained beforehand.
+func (a *App) CheckReadiness(ctx context.Context) ProbeReport {
+	if a.ShuttingDown() {
+		return ProbeReport{
+			Status: ProbeStatusFailed,
+			Reason: ErrShuttingDown.Error(),
+			Checks: []ProbeResult{},
+		}
+	}
+
+	return a.runProbes(ctx, ProbeReadiness)
+}
+
+// CheckLiveness runs all liveness probes concurrently.
+func (a *App) CheckLiveness(ctx context.Context) ProbeReport {
+	return a.runProbes(ctx, ProbeLiveness)
+}
+
+func (a *App) runProbes(ctx context.Context, kind ProbeKind) ProbeReport {
+	a.probeMux.RLock()
+	ps := []*probe{}
+	for _, p := range a.probes {
+		if p.kinds&kind != 0 {
+			ps = append(ps, p)
+		}
+	}
+	a.probeMux.RUnlock()
+
+	results := make([]ProbeResult, len(ps))
+
+	var wg sync.WaitGroup
+	for i := range ps {
+		wg.Add(1)
+		go func(idx int, p *probe) {
+			defer wg.Done()
+			results[idx] = p.run(ctx)
+		}(i, ps[i])
+	}
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := ProbeReport{
+		Status: ProbeStatusOK,
+		Checks: results,
+	}
+	for _, r := range results {
+		if r.Status == ProbeStatusOK {
+			continue
+		}
+
+		if r.Critical {
+			report.Status = ProbeStatusFailed
+			break
+		}
+		report.Status = ProbeStatusDegraded
+	}
+
+	return report
+}
+
+func (p *probe) run(ctx context.Context) ProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	now := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	ret := ProbeResult{
+		Name:     p.name,
+		Status:   ProbeStatusOK,
+		Critical: p.critical,
+		Duration: time.Since(now).String(),
+	}
+	if err != nil {
+		ret.Status = ProbeStatusFailed
+		ret.Error = err.Error()
+	}
+
+	return ret
+}
+
+// AddProbe registers a named check into the default App.
+func AddProbe(name string, check ProbeFunc, options ...ProbeOptions) {
+	defaultApp.AddProbe(name, check, options...)
+}
+
+// ShuttingDown returns true once the default App starts shutting down.
+func ShuttingDown() bool {
+	return defaultApp.ShuttingDown()
+}
+
+// CheckReadiness runs all readiness probes of the default App.
+func CheckReadiness(ctx context.Context) ProbeReport {
+	return defaultApp.CheckReadiness(ctx)
+}
+
+// CheckLiveness runs all liveness probes of the default App.
+func CheckLiveness(ctx context.Context) ProbeReport {
+	return defaultApp.CheckLiveness(ctx)
+}
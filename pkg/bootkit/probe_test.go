@@ -0,0 +1,130 @@
+package bootkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type probeSuite struct {
+	suite.Suite
+
+	app *App
+}
+
+func (s *probeSuite) SetupSuite()    {}
+func (s *probeSuite) TearDownSuite() {}
+func (s *probeSuite) SetupTest()     { s.app = New() }
+func (s *probeSuite) TearDownTest()  {}
+
+func TestProbeSuite(t *testing.T) {
+	suite.Run(t, new(probeSuite))
+}
+
+func (s *probeSuite) TestCheckReadiness() {
+	healthy := func(context.Context) error { return nil }
+	broken := func(context.Context) error { return errors.New("broken") }
+	hung := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		Desc      string
+		SetupTest func(*App)
+		ExpStatus string
+		ExpChecks int
+	}{
+		{
+			Desc:      "no probe",
+			SetupTest: func(*App) {},
+			ExpStatus: ProbeStatusOK,
+			ExpChecks: 0,
+		},
+		{
+			Desc: "all healthy",
+			SetupTest: func(app *App) {
+				app.AddProbe("mysql", healthy)
+				app.AddProbe("cache", healthy)
+			},
+			ExpStatus: ProbeStatusOK,
+			ExpChecks: 2,
+		},
+		{
+			Desc: "critical probe failed",
+			SetupTest: func(app *App) {
+				app.AddProbe("mysql", broken)
+				app.AddProbe("cache", healthy)
+			},
+			ExpStatus: ProbeStatusFailed,
+			ExpChecks: 2,
+		},
+		{
+			Desc: "non-critical probe failed",
+			SetupTest: func(app *App) {
+				app.AddProbe("mysql", healthy)
+				app.AddProbe("cache", broken, WithProbeCritical(false))
+			},
+			ExpStatus: ProbeStatusDegraded,
+			ExpChecks: 2,
+		},
+		{
+			Desc: "probe timed out",
+			SetupTest: func(app *App) {
+				app.AddProbe("mysql", hung, WithProbeTimeout(10*time.Millisecond))
+			},
+			ExpStatus: ProbeStatusFailed,
+			ExpChecks: 1,
+		},
+		{
+			Desc: "liveness probe is excluded",
+			SetupTest: func(app *App) {
+				app.AddProbe("deadlock", broken, WithProbeKinds(ProbeLiveness))
+			},
+			ExpStatus: ProbeStatusOK,
+			ExpChecks: 0,
+		},
+		{
+			Desc: "replace probe with the same name",
+			SetupTest: func(app *App) {
+				app.AddProbe("mysql", broken)
+				app.AddProbe("mysql", healthy)
+			},
+			ExpStatus: ProbeStatusOK,
+			ExpChecks: 1,
+		},
+		{
+			Desc: "shutting down",
+			SetupTest: func(app *App) {
+				app.AddProbe("mysql", healthy)
+				app.shuttingDown.Store(true)
+			},
+			ExpStatus: ProbeStatusFailed,
+			ExpChecks: 0,
+		},
+	}
+
+	for _, t := range tests {
+		app := New()
+		t.SetupTest(app)
+
+		report := app.CheckReadiness(context.Background())
+		s.Require().Equal(t.ExpStatus, report.Status, t.Desc)
+		s.Require().Len(report.Checks, t.ExpChecks, t.Desc)
+	}
+}
+
+func (s *probeSuite) TestCheckLiveness() {
+	s.app.AddProbe("mysql", func(context.Context) error { return errors.New("broken") })
+	s.app.AddProbe("deadlock", func(context.Context) error { return nil }, WithProbeKinds(ProbeReadiness, ProbeLiveness))
+	s.app.shuttingDown.Store(true)
+
+	// liveness doesn't flip during shutdown, otherwise the pod would be restarted.
+	report := s.app.CheckLiveness(context.Background())
+	s.Require().Equal(ProbeStatusOK, report.Status)
+	s.Require().Len(report.Checks, 1)
+	s.Require().Equal("deadlock", report.Checks[0].Name)
+}
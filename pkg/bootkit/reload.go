@@ -0,0 +1,96 @@
+package bootkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"demo/pkg/logger"
+)
+
+// ReloadFunc re-reads configuration of a component without restarting the app.
+type ReloadFunc func(ctx context.Context) error
+
+type reloadHandler struct {
+	name string
+	fn   ReloadFunc
+}
+
+// AddReloadHandler hooks callback function that want to be called on SIGHUP.
+// Handlers run one by one in the order they were added.
+// Adding a handler with an existing name replaces the previous one, ex: the one added by a server before it restarted.
+func (a *App) AddReloadHandler(name string, handler ReloadFunc) {
+	a.reloadHlrMux.Lock()
+	defer a.reloadHlrMux.Unlock()
+
+	h := &reloadHandler{
+		name: name,
+		fn:   handler,
+	}
+
+	for i := range a.reloadHandlers {
+		if a.reloadHandlers[i].name == name {
+			a.reloadHandlers[i] = h
+			return
+		}
+	}
+
+	a.reloadHandlers = append(a.reloadHandlers, h)
+}
+
+// Reload triggers all reload handlers, which is done on SIGHUP automatically once the app runs.
+// Reloads are serialized, and a failed handler doesn't stop the others.
+// It returns errors of all failed handlers joined together.
+func (a *App) Reload(ctx context.Context) error {
+	a.reloadMux.Lock()
+	defer a.reloadMux.Unlock()
+
+	a.reloadHlrMux.Lock()
+	hlrs := make([]*reloadHandler, len(a.reloadHandlers))
+	copy(hlrs, a.reloadHandlers)
+	a.reloadHlrMux.Unlock()
+
+	now := time.Now()
+	errs := []error{}
+	for _, h := range hlrs {
+		if err := h.run(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+
+	err := errors.Join(errs...)
+	fs := logger.Fields{
+		"handlers": len(hlrs),
+		"failed":   len(errs),
+		"duration": time.Since(now).String(),
+	}
+	if err != nil {
+		logger.Ctx(ctx).Error("reload failed", logger.WithError(err), logger.WithFields(fs))
+		return err
+	}
+	logger.Ctx(ctx).Info("reload finished", logger.WithFields(fs))
+
+	return nil
+}
+
+func (h *reloadHandler) run(ctx context.Context) (err error) {
+	// a panicking handler must not take down the process.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrReloadPanic, r)
+		}
+	}()
+
+	return h.fn(ctx)
+}
+
+// AddReloadHandler hooks callback function into the default App that want to be called on SIGHUP.
+func AddReloadHandler(name string, handler ReloadFunc) {
+	defaultApp.AddReloadHandler(name, handler)
+}
+
+// Reload triggers all reload handlers of the default App.
+func Reload(ctx context.Context) error {
+	return defaultApp.Reload(ctx)
+}
@@ -0,0 +1,224 @@
+package bootkit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"demo/pkg/logger"
+)
+
+// ReadyFunc signals that the registered server is ready to serve.
+type ReadyFunc func()
+
+// ServeFunc launches a server running in a infinite loop, calls ready() once it's able to serve.
+type ServeFunc func(shutdown ShutdownFunc, ready ReadyFunc) error
+
+type server struct {
+	name      string
+	dependsOn []string
+	fn        ServeFunc
+
+	policy      RestartPolicy
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	maxRestarts int
+	window      time.Duration
+
+	// shutdownLevel is the level of shutdown handlers added by the server, see assignShutdownLevels()
+	shutdownLevel int
+
+	ready     chan struct{}
+	readyOnce sync.Once
+	startedAt time.Time
+}
+
+// supervise launches the server, and restarts it based on its RestartPolicy until
+// the app is shutting down or the restart budget is used up.
+func (a *App) supervise(ctx context.Context, serv *server, ready ReadyFunc) error {
+	restarts := []time.Time{}
+	for {
+		// shutdown handlers added by the previous run are replaced by the ones of this run.
+		hs := []*shutdownHandler{}
+		shutdown := func(handler handlerFunc, options ...BootOptions) {
+			hs = append(hs, a.addHandler(handler, append(options, withShutdownLevel(serv.shutdownLevel))...))
+		}
+
+		serv.startedAt = time.Now()
+		a.record(Event{Type: EventTypeStarting, Server: serv.name, Restarts: len(restarts)}, nil)
+		err := serv.fn(shutdown, ready)
+
+		ev := Event{Type: EventTypeStopped, Server: serv.name, Restarts: len(restarts), Duration: time.Since(serv.startedAt).String()}
+		if err != nil {
+			ev.Type = EventTypeFailed
+		}
+		a.record(ev, err)
+
+		if !serv.shouldRestart(err) || ctx.Err() != nil || a.ShuttingDown() {
+			return err
+		}
+
+		// only count restarts within the window
+		now := time.Now()
+		for len(restarts) > 0 && now.Sub(restarts[0]) > serv.window {
+			restarts = restarts[1:]
+		}
+		if len(restarts) >= serv.maxRestarts {
+			logger.Ctx(ctx).Error(fmt.Sprintf("server restarted too many times: %s", serv.name),
+				logger.WithError(err),
+				logger.WithFields(logger.Fields{
+					"server":   serv.name,
+					"restarts": len(restarts),
+					"window":   serv.window.String(),
+				}),
+			)
+			if err == nil {
+				return ErrRestartBudgetExceeded
+			}
+			return fmt.Errorf("%w: %v", ErrRestartBudgetExceeded, err)
+		}
+		restarts = append(restarts, now)
+
+		a.removeHandlers(hs)
+
+		backoff := serv.backoffOf(len(restarts))
+		a.record(Event{
+			Type:     EventTypeRestarting,
+			Server:   serv.name,
+			Restarts: len(restarts),
+			Reason:   fmt.Sprintf("restart policy %s, backoff %s", serv.policy, backoff),
+		}, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *server) shouldRestart(err error) bool {
+	switch s.policy {
+	case RestartPolicyAlways:
+		return true
+	case RestartPolicyOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// backoffOf returns the exponential backoff before the nth restart.
+func (s *server) backoffOf(n int) time.Duration {
+	backoff := s.backoff
+	for i := 1; i < n && backoff < s.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > s.maxBackoff {
+		return s.maxBackoff
+	}
+
+	return backoff
+}
+
+// validateServers checks names of servers are unique, and their dependencies exist without any cycle.
+func validateServers(servers []*server) error {
+	m := map[string]*server{}
+	for _, s := range servers {
+		if _, ok := m[s.name]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicatedServer, s.name)
+		}
+		m[s.name] = s
+	}
+
+	for _, s := range servers {
+		for _, dep := range s.dependsOn {
+			if _, ok := m[dep]; !ok {
+				return fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, s.name, dep)
+			}
+		}
+	}
+
+	// depth-first search to detect cycles
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := map[string]int{}
+	var visit func(s *server) error
+	visit = func(s *server) error {
+		switch states[s.name] {
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrCyclicDependency, s.name)
+		case visited:
+			return nil
+		}
+
+		states[s.name] = visiting
+		for _, dep := range s.dependsOn {
+			if err := visit(m[dep]); err != nil {
+				return err
+			}
+		}
+		states[s.name] = visited
+
+		return nil
+	}
+
+	for _, s := range servers {
+		if err := visit(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assignShutdownLevels shuts servers down before the ones they depend on, ex: the gateway before the gRPC server,
+// so requests accepted by the former are still served by the latter while draining.
+// Servers without dependencies are at topPriorityShutdownLevel, and dependents go one level earlier each.
+// The servers must be validated by validateServers().
+func assignShutdownLevels(servers []*server) {
+	m := map[string]*server{}
+	for _, s := range servers {
+		m[s.name] = s
+	}
+
+	// depth is the length of the longest dependency chain below the server
+	depths := map[string]int{}
+	var depth func(s *server) int
+	depth = func(s *server) int {
+		if d, ok := depths[s.name]; ok {
+			return d
+		}
+
+		d := 0
+		for _, dep := range s.dependsOn {
+			d = max(d, depth(m[dep])+1)
+		}
+		depths[s.name] = d
+
+		return d
+	}
+
+	for _, s := range servers {
+		s.shutdownLevel = topPriorityShutdownLevel - depth(s)
+	}
+}
+
+// notReady returns names of servers which haven't signalled ready yet.
+func notReady(servers []*server) []string {
+	names := []string{}
+	for _, s := range servers {
+		select {
+		case <-s.ready:
+		default:
+			names = append(names, s.name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,136 @@
+package bootkit
+
+import (
+	"sync"
+	"time"
+)
+
+type shutdownHandler struct {
+	name    string
+	level   int
+	timeout time.Duration
+	fn      handlerFunc
+}
+
+type orderedHandler struct {
+	level    int
+	handlers []*shutdownHandler
+}
+
+// ShutdownResult is the outcome of a single shutdown handler.
+type ShutdownResult struct {
+	Name     string
+	Level    int
+	Duration time.Duration
+	Err      error
+	TimedOut bool
+	Skipped  bool
+}
+
+// ShutdownReport summarizes the whole shutdown process.
+type ShutdownReport struct {
+	Duration time.Duration
+	Results  []ShutdownResult
+}
+
+// TimedOut returns names of handlers which didn't finish within their deadline.
+func (r ShutdownReport) TimedOut() []string {
+	names := []string{}
+	for _, ret := range r.Results {
+		if ret.TimedOut {
+			names = append(names, ret.Name)
+		}
+	}
+
+	return names
+}
+
+// Skipped returns names of handlers which never ran because the shutdown budget was used up.
+func (r ShutdownReport) Skipped() []string {
+	names := []string{}
+	for _, ret := range r.Results {
+		if ret.Skipped {
+			names = append(names, ret.Name)
+		}
+	}
+
+	return names
+}
+
+// run triggers registered callbacks at the same level concurrently, and waits for them until the deadline.
+// finished is called once each of them returns or times out.
+func (o orderedHandler) run(deadline time.Time, finished func(ShutdownResult)) []ShutdownResult {
+	results := make([]ShutdownResult, len(o.handlers))
+
+	var wg sync.WaitGroup
+	// Deliver the last-in handler at the same level as possible.
+	for i := len(o.handlers) - 1; i >= 0; i-- {
+		wg.Add(1)
+		go func(idx int, h *shutdownHandler) {
+			defer wg.Done()
+			results[idx] = h.run(deadline)
+			finished(results[idx])
+		}(i, o.handlers[i])
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (o orderedHandler) skip() []ShutdownResult {
+	results := make([]ShutdownResult, len(o.handlers))
+	for i, h := range o.handlers {
+		results[i] = ShutdownResult{
+			Name:    h.name,
+			Level:   h.level,
+			Err:     ErrShutdownSkipped,
+			Skipped: true,
+		}
+	}
+
+	return results
+}
+
+func (h *shutdownHandler) run(deadline time.Time) ShutdownResult {
+	now := time.Now()
+	if h.timeout > 0 {
+		deadline = earlier(deadline, now.Add(h.timeout))
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- h.fn()
+	}()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		t := time.NewTimer(time.Until(deadline))
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	ret := ShutdownResult{
+		Name:  h.name,
+		Level: h.level,
+	}
+
+	select {
+	case err := <-errCh:
+		ret.Err = err
+	case <-timeout:
+		ret.Err = ErrShutdownTimeout
+		ret.TimedOut = true
+	}
+	ret.Duration = time.Since(now)
+
+	return ret
+}
+
+// earlier returns the earlier one of both deadlines, a zero time means no deadline.
+func earlier(a, b time.Time) time.Time {
+	if a.IsZero() || b.Before(a) {
+		return b
+	}
+
+	return a
+}
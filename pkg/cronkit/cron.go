@@ -0,0 +1,260 @@
+package cronkit
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidCron indicates the cron expression can't be parsed.
+	ErrInvalidCron = errors.New("invalid cron expression")
+)
+
+// Schedule describes when a job runs.
+type Schedule interface {
+	// Next returns the next activation time later than t, or zero time if there isn't any.
+	Next(t time.Time) time.Time
+}
+
+type bounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteBounds = bounds{0, 59, nil}
+	hourBounds   = bounds{0, 23, nil}
+	domBounds    = bounds{1, 31, nil}
+	monthBounds  = bounds{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = bounds{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	descriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// cronSchedule keeps allowed values of each field as bits.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+	loc                           *time.Location
+}
+
+// ParseCron parses a standard cron expression with five fields in the local time zone.
+//
+//	┌───────────── minute (0-59)
+//	│ ┌───────────── hour (0-23)
+//	│ │ ┌───────────── day of the month (1-31)
+//	│ │ │ ┌───────────── month (1-12 or JAN-DEC)
+//	│ │ │ │ ┌───────────── day of the week (0-6 or SUN-SAT, 7 is also Sunday)
+//	│ │ │ │ │
+//	* * * * *
+//
+// Each field accepts `*`, values, ranges (1-5), steps (*/15, 0-30/10) and lists (1,15,30).
+// Descriptors like @hourly, @daily, @weekly, @monthly, @yearly and `@every <duration>` are supported as well.
+func ParseCron(expr string) (Schedule, error) {
+	return ParseCronInLocation(expr, time.Local)
+}
+
+// ParseCronInLocation parses a cron expression as ParseCron() does in the specified time zone.
+func ParseCronInLocation(expr string, loc *time.Location) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCron, expr)
+		}
+		return Every(d), nil
+	}
+
+	if d, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d: %s", ErrInvalidCron, len(fields), expr)
+	}
+
+	s := &cronSchedule{loc: loc}
+	var err error
+	if s.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], domBounds); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, err
+	}
+	// 7 is also Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+
+	return s, nil
+}
+
+// MustParseCron is like ParseCron but panics if the expression cannot be parsed.
+func MustParseCron(expr string) Schedule {
+	s, err := ParseCron(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if idx := strings.Index(part, "/"); idx != -1 {
+			s, err := strconv.Atoi(part[idx+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("%w: invalid step: %s", ErrInvalidCron, part)
+			}
+			rng, step = part[:idx], s
+		}
+
+		start, end := b.min, b.max
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			idx := strings.Index(rng, "-")
+			var err error
+			if start, err = parseValue(rng[:idx], b); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(rng[idx+1:], b); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := parseValue(rng, b)
+			if err != nil {
+				return 0, err
+			}
+			start = v
+			// a single value with step means the range until the max, ex: 5/15
+			end = v
+			if step > 1 {
+				end = b.max
+			}
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("%w: invalid range: %s", ErrInvalidCron, part)
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(s string, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < b.min || v > b.max {
+		return 0, fmt.Errorf("%w: value out of range [%d, %d]: %s", ErrInvalidCron, b.min, b.max, s)
+	}
+
+	return v, nil
+}
+
+// Next returns the next time matching the expression, it gives up after 5 years.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(s.loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, s.loc).Add(time.Minute)
+	yearLimit := t.Year() + 5
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.loc)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	return t.In(origLoc)
+}
+
+// dayMatches follows the cron convention:
+// if both day of the month and day of the week are restricted, either of them matching is enough.
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Every returns a Schedule activating once every interval since the previous run.
+func Every(interval time.Duration) Schedule {
+	return &everySchedule{interval: interval}
+}
+
+func (s *everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
@@ -0,0 +1,133 @@
+package cronkit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type cronSuite struct {
+	suite.Suite
+}
+
+func (s *cronSuite) SetupSuite()    {}
+func (s *cronSuite) TearDownSuite() {}
+func (s *cronSuite) SetupTest()     {}
+func (s *cronSuite) TearDownTest()  {}
+
+func TestCronSuite(t *testing.T) {
+	suite.Run(t, new(cronSuite))
+}
+
+func (s *cronSuite) TestNext() {
+	// Wednesday
+	from := time.Date(2024, 5, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		Desc    string
+		Expr    string
+		ExpNext time.Time
+	}{
+		{
+			Desc:    "every minute",
+			Expr:    "* * * * *",
+			ExpNext: time.Date(2024, 5, 15, 10, 8, 0, 0, time.UTC),
+		},
+		{
+			Desc:    "step",
+			Expr:    "*/15 * * * *",
+			ExpNext: time.Date(2024, 5, 15, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			Desc:    "list and range",
+			Expr:    "0 9-11,22 * * *",
+			ExpNext: time.Date(2024, 5, 15, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			Desc:    "next day",
+			Expr:    "30 2 * * *",
+			ExpNext: time.Date(2024, 5, 16, 2, 30, 0, 0, time.UTC),
+		},
+		{
+			Desc:    "day of the week by name",
+			Expr:    "0 0 * * mon",
+			ExpNext: time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Desc:    "7 is Sunday",
+			Expr:    "0 0 * * 7",
+			ExpNext: time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Desc:    "day of the month or day of the week",
+			Expr:    "0 0 1 * fri",
+			ExpNext: time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Desc:    "month by name",
+			Expr:    "0 0 1 FEB *",
+			ExpNext: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Desc:    "leap day",
+			Expr:    "0 0 29 2 *",
+			ExpNext: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Desc:    "descriptor",
+			Expr:    "@monthly",
+			ExpNext: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Desc:    "every",
+			Expr:    "@every 90s",
+			ExpNext: time.Date(2024, 5, 15, 10, 9, 0, 0, time.UTC),
+		},
+	}
+
+	for _, t := range tests {
+		sched, err := ParseCronInLocation(t.Expr, time.UTC)
+		s.Require().NoError(err, t.Desc)
+		s.Require().Equal(t.ExpNext, sched.Next(from), t.Desc)
+	}
+}
+
+func (s *cronSuite) TestNextInLocation() {
+	loc, err := time.LoadLocation("Asia/Taipei")
+	s.Require().NoError(err)
+
+	sched, err := ParseCronInLocation("0 9 * * *", loc)
+	s.Require().NoError(err)
+
+	next := sched.Next(time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC))
+	s.Require().Equal(time.Date(2024, 5, 15, 1, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func (s *cronSuite) TestNextNever() {
+	sched, err := ParseCronInLocation("0 0 30 2 *", time.UTC)
+	s.Require().NoError(err)
+	s.Require().True(sched.Next(time.Now()).IsZero())
+}
+
+func (s *cronSuite) TestParseCronFailed() {
+	tests := []struct {
+		Desc string
+		Expr string
+	}{
+		{Desc: "empty", Expr: ""},
+		{Desc: "too few fields", Expr: "* * * *"},
+		{Desc: "too many fields", Expr: "0 * * * * *"},
+		{Desc: "out of range", Expr: "60 * * * *"},
+		{Desc: "invalid range", Expr: "0 5-2 * * *"},
+		{Desc: "invalid step", Expr: "*/0 * * * *"},
+		{Desc: "unknown name", Expr: "0 0 * * fun"},
+		{Desc: "unknown descriptor", Expr: "@sometimes"},
+		{Desc: "invalid every", Expr: "@every -1s"},
+	}
+
+	for _, t := range tests {
+		_, err := ParseCron(t.Expr)
+		s.Require().ErrorIs(err, ErrInvalidCron, t.Desc)
+	}
+}
@@ -0,0 +1,5 @@
+// Package cronkit runs periodic and cron-scheduled background jobs.
+// The scheduler is launched as a server in bootkit, so jobs follow its startup and shutdown ordering.
+// Each run gets a context cancelled on shutdown, and in-flight runs are drained before the scheduler stops.
+// Singleton jobs can hold a Locker, ex: MySQL named locks, so only one pod runs them at a time.
+package cronkit
@@ -0,0 +1,94 @@
+package cronkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrLockNotAcquired indicates the lock is held by others.
+	ErrLockNotAcquired = errors.New("lock not acquired")
+)
+
+// Locker coordinates singleton jobs across pods.
+type Locker interface {
+	// TryLock acquires the named lock without waiting, and returns the function releasing it.
+	// It returns ErrLockNotAcquired if the lock is held by others.
+	TryLock(ctx context.Context, name string, ttl time.Duration) (unlock func(), err error)
+}
+
+// NewMySQLLocker returns a Locker based on MySQL named locks (GET_LOCK/RELEASE_LOCK).
+// The lock is bound to a dedicated connection, so it's released automatically once the pod dies.
+// i.e.,
+// sqlDB, _ := gormDB.DB()
+// sched.AddJob("cleanup", cronkit.MustParseCron("@hourly"), cleanup, cronkit.WithSingleton(cronkit.NewMySQLLocker(sqlDB), time.Hour))
+func NewMySQLLocker(db *sql.DB) Locker {
+	return &mysqlLocker{db: db}
+}
+
+type mysqlLocker struct {
+	db *sql.DB
+}
+
+func (l *mysqlLocker) TryLock(ctx context.Context, name string, ttl time.Duration) (func(), error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// GET_LOCK returns 1 if acquired, 0 if held by others, NULL on error.
+	var ret sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", lockName(name)).Scan(&ret); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !ret.Valid {
+		conn.Close()
+		return nil, fmt.Errorf("GET_LOCK failed: %s", name)
+	}
+	if ret.Int64 != 1 {
+		conn.Close()
+		return nil, ErrLockNotAcquired
+	}
+
+	// release the lock by discarding the connection once the ttl passes, in case the run hangs.
+	var timer *time.Timer
+	if ttl > 0 {
+		timer = time.AfterFunc(ttl, func() { discardConn(conn) })
+	}
+
+	return func() {
+		if timer != nil && !timer.Stop() {
+			return
+		}
+		// use a fresh context since the one of the run may be cancelled already
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName(name)); err != nil {
+			discardConn(conn)
+			return
+		}
+		conn.Close()
+	}, nil
+}
+
+// discardConn closes the physical connection, which ends the session holding the lock.
+// Close() of sql.Conn returns the connection to the pool, where the lock would be held indefinitely.
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	conn.Close()
+}
+
+// lockName prefixes the job name, MySQL limits the name of a lock to 64 characters.
+func lockName(name string) string {
+	n := "cronkit:" + name
+	if len(n) > 64 {
+		n = n[:64]
+	}
+
+	return n
+}
@@ -0,0 +1,119 @@
+package cronkit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+)
+
+type lockSuite struct {
+	suite.Suite
+}
+
+func (s *lockSuite) SetupSuite()    {}
+func (s *lockSuite) TearDownSuite() {}
+func (s *lockSuite) SetupTest()     {}
+func (s *lockSuite) TearDownTest()  {}
+
+func TestLockSuite(t *testing.T) {
+	suite.Run(t, new(lockSuite))
+}
+
+const (
+	getLock     = `SELECT GET_LOCK\(\?, 0\)`
+	releaseLock = `SELECT RELEASE_LOCK\(\?\)`
+)
+
+func (s *lockSuite) TestMySQLLockerTryLock() {
+	tests := []struct {
+		Desc   string
+		Expect func(sqlmock.Sqlmock)
+		ExpErr error
+		ErrMsg string
+	}{
+		{
+			Desc: "acquired",
+			Expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(getLock).WithArgs("cronkit:job").
+					WillReturnRows(sqlmock.NewRows([]string{"ret"}).AddRow(1))
+			},
+		},
+		{
+			Desc: "held by others",
+			Expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(getLock).WithArgs("cronkit:job").
+					WillReturnRows(sqlmock.NewRows([]string{"ret"}).AddRow(0))
+			},
+			ExpErr: ErrLockNotAcquired,
+		},
+		{
+			Desc: "null",
+			Expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(getLock).WithArgs("cronkit:job").
+					WillReturnRows(sqlmock.NewRows([]string{"ret"}).AddRow(nil))
+			},
+			ErrMsg: "GET_LOCK failed: job",
+		},
+	}
+
+	for _, t := range tests {
+		db, m, err := sqlmock.New()
+		s.Require().NoError(err, t.Desc)
+		t.Expect(m)
+
+		unlock, err := NewMySQLLocker(db).TryLock(context.Background(), "job", 0)
+		switch {
+		case t.ExpErr != nil:
+			s.Require().ErrorIs(err, t.ExpErr, t.Desc)
+		case t.ErrMsg != "":
+			s.Require().EqualError(err, t.ErrMsg, t.Desc)
+		default:
+			s.Require().NoError(err, t.Desc)
+			s.Require().NotNil(unlock, t.Desc)
+		}
+		s.Require().NoError(m.ExpectationsWereMet(), t.Desc)
+		db.Close()
+	}
+}
+
+func (s *lockSuite) TestMySQLLockerUnlock() {
+	db, m, err := sqlmock.New()
+	s.Require().NoError(err)
+	defer db.Close()
+
+	m.ExpectQuery(getLock).WithArgs("cronkit:job").WillReturnRows(sqlmock.NewRows([]string{"ret"}).AddRow(1))
+	m.ExpectExec(releaseLock).WithArgs("cronkit:job").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	unlock, err := NewMySQLLocker(db).TryLock(context.Background(), "job", time.Minute)
+	s.Require().NoError(err)
+	unlock()
+
+	// the connection goes back to the pool without the lock
+	s.Require().NoError(m.ExpectationsWereMet())
+	s.Require().Equal(1, db.Stats().Idle)
+}
+
+func (s *lockSuite) TestMySQLLockerTTL() {
+	db, m, err := sqlmock.New()
+	s.Require().NoError(err)
+	defer db.Close()
+
+	m.ExpectQuery(getLock).WithArgs("cronkit:job").WillReturnRows(sqlmock.NewRows([]string{"ret"}).AddRow(1))
+	// the session holding the lock is closed instead of going back to the pool
+	m.ExpectClose()
+
+	unlock, err := NewMySQLLocker(db).TryLock(context.Background(), "job", 50*time.Millisecond)
+	s.Require().NoError(err)
+
+	s.Require().Eventually(func() bool {
+		return m.ExpectationsWereMet() == nil
+	}, time.Second, 10*time.Millisecond)
+	s.Require().Zero(db.Stats().OpenConnections)
+
+	// unlocking after the ttl is a no-op
+	unlock()
+	s.Require().NoError(m.ExpectationsWereMet())
+}
@@ -0,0 +1,114 @@
+package cronkit
+
+import "time"
+
+// JobOptions sets the job options.
+type JobOptions interface {
+	apply(*jobOptions)
+}
+
+type jobOptions struct {
+	jitter  time.Duration
+	timeout time.Duration
+	locker  Locker
+	lockTTL time.Duration
+}
+
+// EmptyJobOption does not alter the job configuration. It can be embedded
+// in another structure to build custom job options.
+type EmptyJobOption struct{}
+
+func (EmptyJobOption) apply(*jobOptions) {}
+
+// funcJobOption wraps a function that modifies jobOptions into an
+// implementation of the JobOptions interface.
+type funcJobOption struct {
+	f func(*jobOptions)
+}
+
+func (o *funcJobOption) apply(do *jobOptions) {
+	o.f(do)
+}
+
+func newFuncJobOption(f func(*jobOptions)) *funcJobOption {
+	return &funcJobOption{
+		f: f,
+	}
+}
+
+// WithJitter delays each run by a random duration in [0, jitter),
+// which spreads the load when many pods run the same job at the same time.
+func WithJitter(jitter time.Duration) JobOptions {
+	return newFuncJobOption(func(opts *jobOptions) {
+		opts.jitter = jitter
+	})
+}
+
+// WithTimeout specifies how long a single run can take before its context is cancelled.
+// Zero means no timeout, which is the default.
+func WithTimeout(timeout time.Duration) JobOptions {
+	return newFuncJobOption(func(opts *jobOptions) {
+		opts.timeout = timeout
+	})
+}
+
+// WithSingleton makes the job run on only one pod at a time by holding the lock named after the job.
+// The run is skipped if another pod holds the lock. ttl bounds how long the lock is held
+// in case the pod holding it hangs, it's the timeout of the run by default.
+func WithSingleton(locker Locker, ttl time.Duration) JobOptions {
+	return newFuncJobOption(func(opts *jobOptions) {
+		opts.locker = locker
+		opts.lockTTL = ttl
+	})
+}
+
+func applyJobOptions(options ...JobOptions) *jobOptions {
+	opts := &jobOptions{}
+	for _, o := range options {
+		o.apply(opts)
+	}
+
+	return opts
+}
+
+// SchedOptions sets the scheduler options.
+type SchedOptions interface {
+	apply(*schedOptions)
+}
+
+type schedOptions struct {
+	drainTimeout time.Duration
+}
+
+// funcSchedOption wraps a function that modifies schedOptions into an
+// implementation of the SchedOptions interface.
+type funcSchedOption struct {
+	f func(*schedOptions)
+}
+
+func (o *funcSchedOption) apply(do *schedOptions) {
+	o.f(do)
+}
+
+func newFuncSchedOption(f func(*schedOptions)) *funcSchedOption {
+	return &funcSchedOption{
+		f: f,
+	}
+}
+
+// WithDrainTimeout specifies how long the scheduler waits for in-flight runs on shutdown.
+// Zero means waiting until the shutdown budget of bootkit is used up, which is the default.
+func WithDrainTimeout(timeout time.Duration) SchedOptions {
+	return newFuncSchedOption(func(opts *schedOptions) {
+		opts.drainTimeout = timeout
+	})
+}
+
+func applySchedOptions(options ...SchedOptions) *schedOptions {
+	opts := &schedOptions{}
+	for _, o := range options {
+		o.apply(opts)
+	}
+
+	return opts
+}
@@ -0,0 +1,185 @@
+package cronkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"demo/pkg/bootkit"
+	"demo/pkg/logger"
+)
+
+var (
+	// ErrDuplicatedJob indicates more than one job added with the same name.
+	ErrDuplicatedJob = errors.New("duplicated job")
+	// ErrInvalidJob indicates a job added without schedule or function.
+	ErrInvalidJob = errors.New("invalid job")
+	// ErrJobPanic indicates a run of the job panicked.
+	ErrJobPanic = errors.New("job panicked")
+)
+
+// JobFunc runs a job. ctx is cancelled on shutdown or once the run times out.
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	schedule Schedule
+	fn       JobFunc
+	opts     *jobOptions
+}
+
+// Scheduler runs jobs based on their Schedule until shutdown.
+type Scheduler struct {
+	jobs   []*job
+	jobMux sync.Mutex
+
+	opts *schedOptions
+}
+
+// New returns a Scheduler. Launch it in bootkit with Serve().
+func New(options ...SchedOptions) *Scheduler {
+	return &Scheduler{
+		jobs: []*job{},
+		opts: applySchedOptions(options...),
+	}
+}
+
+// AddJob adds the named job running based on the schedule, ex: ParseCron("*/5 * * * *"), Every(time.Minute).
+// Runs of the same job never overlap, the next run is scheduled after the previous one finished.
+func (s *Scheduler) AddJob(name string, schedule Schedule, fn JobFunc, options ...JobOptions) error {
+	if schedule == nil || fn == nil {
+		return fmt.Errorf("%w: %s", ErrInvalidJob, name)
+	}
+
+	s.jobMux.Lock()
+	defer s.jobMux.Unlock()
+
+	for _, j := range s.jobs {
+		if j.name == name {
+			return fmt.Errorf("%w: %s", ErrDuplicatedJob, name)
+		}
+	}
+
+	s.jobs = append(s.jobs, &job{
+		name:     name,
+		schedule: schedule,
+		fn:       fn,
+		opts:     applyJobOptions(options...),
+	})
+
+	return nil
+}
+
+// Serve launches all jobs and blocks until shutdown. It implements bootkit.ServeFunc.
+// i.e.,
+// bootkit.RegisterServer("scheduler", sched.Serve)
+func (s *Scheduler) Serve(shutdown bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
+	s.jobMux.Lock()
+	jobs := make([]*job, len(s.jobs))
+	copy(jobs, s.jobs)
+	s.jobMux.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	stopped := make(chan struct{})
+	shutdown(func() error {
+		logger.Info("Shutting down the scheduler ...")
+		// cancel contexts of in-flight runs and wait for them
+		cancel()
+		wg.Wait()
+		close(stopped)
+		return nil
+	}, bootkit.WithShutdownName("cronkit-scheduler"), bootkit.WithShutdownTimeout(s.opts.drainTimeout))
+
+	for _, j := range jobs {
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+
+	logger.Info(fmt.Sprintf("Starting scheduler with %d jobs", len(jobs)))
+	ready()
+
+	<-stopped
+	return nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			logger.Warn(fmt.Sprintf("job has no next run: %s", j.name), logger.WithField("job", j.name))
+			return
+		}
+
+		wait := time.Until(next)
+		if j.opts.jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(j.opts.jitter)))
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+
+		s.run(ctx, j)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, j *job) {
+	if j.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.opts.timeout)
+		defer cancel()
+	}
+
+	ctx = logger.ContextWithFields(ctx, logger.Fields{"job": j.name})
+
+	if j.opts.locker != nil {
+		ttl := j.opts.lockTTL
+		if ttl == 0 {
+			ttl = j.opts.timeout
+		}
+
+		unlock, err := j.opts.locker.TryLock(ctx, j.name, ttl)
+		if errors.Is(err, ErrLockNotAcquired) {
+			logger.Ctx(ctx).Debug(fmt.Sprintf("skip job held by others: %s", j.name))
+			return
+		}
+		if err != nil {
+			logger.Ctx(ctx).Error("locker.TryLock failed", logger.WithError(err))
+			return
+		}
+		defer unlock()
+	}
+
+	now := time.Now()
+	err := j.exec(ctx)
+	fs := logger.Fields{"duration": time.Since(now).String()}
+	if err != nil {
+		logger.Ctx(ctx).Error(fmt.Sprintf("job failed: %s", j.name), logger.WithError(err), logger.WithFields(fs))
+		return
+	}
+	logger.Ctx(ctx).Debug(fmt.Sprintf("job finished: %s", j.name), logger.WithFields(fs))
+}
+
+func (j *job) exec(ctx context.Context) (err error) {
+	// a panicking job must not take down the process.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrJobPanic, r)
+		}
+	}()
+
+	return j.fn(ctx)
+}
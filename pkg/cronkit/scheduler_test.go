@@ -0,0 +1,160 @@
+package cronkit
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"demo/pkg/bootkit"
+)
+
+type schedulerSuite struct {
+	suite.Suite
+}
+
+func (s *schedulerSuite) SetupSuite()    {}
+func (s *schedulerSuite) TearDownSuite() {}
+func (s *schedulerSuite) SetupTest()     {}
+func (s *schedulerSuite) TearDownTest()  {}
+
+func TestSchedulerSuite(t *testing.T) {
+	suite.Run(t, new(schedulerSuite))
+}
+
+type mockLocker struct {
+	acquired bool
+	unlocked atomic.Int32
+}
+
+func (l *mockLocker) TryLock(context.Context, string, time.Duration) (func(), error) {
+	if !l.acquired {
+		return nil, ErrLockNotAcquired
+	}
+
+	return func() { l.unlocked.Add(1) }, nil
+}
+
+// serve runs the scheduler in a new bootkit.App for d, then shuts it down.
+func (s *schedulerSuite) serve(sched *Scheduler, d time.Duration) {
+	app := bootkit.New()
+	app.RegisterServer("scheduler", sched.Serve)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(app.Run(ctx))
+
+	time.Sleep(d)
+	cancel()
+	app.Wait()
+}
+
+func (s *schedulerSuite) TestAddJob() {
+	sched := New()
+	noop := func(context.Context) error { return nil }
+
+	s.Require().NoError(sched.AddJob("job", Every(time.Second), noop))
+	s.Require().ErrorIs(sched.AddJob("job", Every(time.Second), noop), ErrDuplicatedJob)
+	s.Require().ErrorIs(sched.AddJob("no-schedule", nil, noop), ErrInvalidJob)
+	s.Require().ErrorIs(sched.AddJob("no-func", Every(time.Second), nil), ErrInvalidJob)
+}
+
+func (s *schedulerSuite) TestServe() {
+	var runs, panics atomic.Int32
+
+	sched := New()
+	s.Require().NoError(sched.AddJob("count", Every(10*time.Millisecond), func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}))
+	s.Require().NoError(sched.AddJob("panic", Every(10*time.Millisecond), func(context.Context) error {
+		panics.Add(1)
+		panic("oops")
+	}))
+
+	s.serve(sched, 100*time.Millisecond)
+
+	s.Require().GreaterOrEqual(runs.Load(), int32(2))
+	// a panicking job keeps being scheduled
+	s.Require().GreaterOrEqual(panics.Load(), int32(2))
+
+	// no more runs after shutdown
+	stopped := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	s.Require().Equal(stopped, runs.Load())
+}
+
+func (s *schedulerSuite) TestShutdown() {
+	var started, cancelled atomic.Bool
+
+	sched := New()
+	s.Require().NoError(sched.AddJob("long", Every(10*time.Millisecond), func(ctx context.Context) error {
+		if !started.CompareAndSwap(false, true) {
+			return nil
+		}
+
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		cancelled.Store(true)
+		return ctx.Err()
+	}))
+
+	s.serve(sched, 50*time.Millisecond)
+
+	// in-flight runs are cancelled and drained before shutdown finishes
+	s.Require().True(started.Load())
+	s.Require().True(cancelled.Load())
+}
+
+func (s *schedulerSuite) TestTimeout() {
+	var deadlineExceeded atomic.Bool
+
+	sched := New()
+	s.Require().NoError(sched.AddJob("slow", Every(10*time.Millisecond), func(ctx context.Context) error {
+		<-ctx.Done()
+		deadlineExceeded.Store(ctx.Err() == context.DeadlineExceeded)
+		return ctx.Err()
+	}, WithTimeout(5*time.Millisecond)))
+
+	s.serve(sched, 50*time.Millisecond)
+
+	s.Require().True(deadlineExceeded.Load())
+}
+
+func (s *schedulerSuite) TestSingleton() {
+	tests := []struct {
+		Desc        string
+		Locker      *mockLocker
+		ExpRun      bool
+		ExpUnlocked bool
+	}{
+		{
+			Desc:        "lock acquired",
+			Locker:      &mockLocker{acquired: true},
+			ExpRun:      true,
+			ExpUnlocked: true,
+		},
+		{
+			Desc:        "lock held by others",
+			Locker:      &mockLocker{acquired: false},
+			ExpRun:      false,
+			ExpUnlocked: false,
+		},
+	}
+
+	for _, t := range tests {
+		var runs atomic.Int32
+
+		sched := New()
+		s.Require().NoError(sched.AddJob("singleton", Every(10*time.Millisecond), func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}, WithSingleton(t.Locker, time.Minute)))
+
+		s.serve(sched, 50*time.Millisecond)
+
+		s.Require().Equal(t.ExpRun, runs.Load() > 0, t.Desc)
+		s.Require().Equal(t.ExpUnlocked, t.Locker.unlocked.Load() > 0, t.Desc)
+	}
+}
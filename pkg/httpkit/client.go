@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"demo/pkg/logger"
+	"demo/pkg/telemetrykit"
 
 	"github.com/gorilla/schema"
 )
@@ -53,15 +54,20 @@ func (s *sender) Send(
 		}
 	}
 
+	span := startClientSpan(ctx, req)
+	defer span.End()
+
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
 	resp, err := client.Do(req)
 	if err != nil {
+		span.RecordError(err)
 		logger.Ctx(ctx).Error("failed to send request", logger.WithField("error", err))
 		return 0, nil, err
 	}
 	defer resp.Body.Close()
+	span.SetAttribute("http.status_code", resp.StatusCode)
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -78,12 +84,18 @@ func (s *sender) SendReturnCSV(
 
 	client := &http.Client{}
 	req, _ := http.NewRequest("GET", path, nil)
+
+	span := startClientSpan(ctx, req)
+	defer span.End()
+
 	resp, err := client.Do(req)
 	if err != nil {
+		span.RecordError(err)
 		logger.Ctx(ctx).Error("failed to send request", logger.WithField("error", err))
 		return 0, nil, err
 	}
 	defer resp.Body.Close()
+	span.SetAttribute("http.status_code", resp.StatusCode)
 
 	reader := csv.NewReader(resp.Body)
 	reader.FieldsPerRecord = -1 // 不檢查每一行的字段數量
@@ -97,6 +109,17 @@ func (s *sender) SendReturnCSV(
 	return resp.StatusCode, body, nil
 }
 
+// startClientSpan starts the span of the outbound request, and propagates the trace to the server in its headers.
+func startClientSpan(ctx context.Context, req *http.Request) telemetrykit.Span {
+	_, span := telemetrykit.Start(ctx, req.Method+" "+req.URL.Host, telemetrykit.SpanKindClient, nil)
+	span.SetAttribute("http.method", req.Method)
+	// the query is omitted since it may contain credentials
+	span.SetAttribute("http.url", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+	span.Inject(telemetrykit.HeaderCarrier(req.Header))
+
+	return span
+}
+
 func reqWithURLForm(ctx context.Context, method, uri string, v interface{}) (*http.Request, error) {
 	// http will wrap the body as URLEncoded if the content-type is application/x-www-form-urlencoded
 	//
@@ -18,6 +18,8 @@ type NRHandler struct {
 
 // NewNRHttpHandler returns a new NRHandler for New Relic integration. Use the Handler and HandleFunc methods to wrap
 // existing HTTP handlers.
+//
+// Deprecated: servkit traces gateway requests with the provider of telemetrykit, ex: telemetrykit.NewNewRelic().
 func NewNRHttpHandler(app *newrelic.Application) *NRHandler {
 	return &NRHandler{app: app}
 }
@@ -0,0 +1,5 @@
+// Package idempotkit keeps the responses of requests by their idempotency keys, so retried requests are
+// answered with the first response instead of applying their side effects again.
+// A key is reserved while its request is in flight, and holds the response once the request completes.
+// Stores are in memory or in MySQL, which makes pods share the keys.
+package idempotkit
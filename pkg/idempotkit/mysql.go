@@ -0,0 +1,110 @@
+package idempotkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// erDupEntry is the MySQL error number of duplicate keys.
+const erDupEntry = 1062
+
+// sweepBatch is the number of expired keys the MySQL store drops at a time.
+const sweepBatch = 1000
+
+// NewMySQLStore returns a Store shared among pods in the MySQL table `idempotency_keys`, i.e.,
+// CREATE TABLE idempotency_keys (idem_key VARCHAR(255) PRIMARY KEY, response MEDIUMBLOB, expire_at DATETIME(6))
+// Expired keys are dropped in batches once a minute while the store is in use.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+type mysqlStore struct {
+	db *sql.DB
+
+	mux       sync.Mutex
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func (s *mysqlStore) Reserve(ctx context.Context, key string, ttl time.Duration) ([]byte, error) {
+	now := s.now().UTC()
+	s.sweep(ctx, now)
+
+	// claim the key if it's new
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO idempotency_keys (idem_key, response, expire_at) VALUES (?, NULL, ?)", key, now.Add(ttl))
+	if err == nil {
+		return nil, nil
+	}
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) || myErr.Number != erDupEntry {
+		return nil, err
+	}
+
+	// take the key over if it has expired
+	ret, err := s.db.ExecContext(ctx,
+		"UPDATE idempotency_keys SET response = NULL, expire_at = ? WHERE idem_key = ? AND expire_at <= ?",
+		now.Add(ttl), key, now)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := ret.RowsAffected(); err != nil {
+		return nil, err
+	} else if n > 0 {
+		return nil, nil
+	}
+
+	var resp []byte
+	err = s.db.QueryRowContext(ctx, "SELECT response FROM idempotency_keys WHERE idem_key = ?", key).Scan(&resp)
+	if errors.Is(err, sql.ErrNoRows) {
+		// released by the request in flight just now, which can be retried
+		return nil, ErrInFlight
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, ErrInFlight
+	}
+
+	return resp, nil
+}
+
+func (s *mysqlStore) Complete(ctx context.Context, key string, resp []byte, ttl time.Duration) error {
+	if resp == nil {
+		resp = []byte{}
+	}
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO idempotency_keys (idem_key, response, expire_at) VALUES (?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE response = VALUES(response), expire_at = VALUES(expire_at)",
+		key, resp, s.now().UTC().Add(ttl))
+
+	return err
+}
+
+func (s *mysqlStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE idem_key = ? AND response IS NULL", key)
+
+	return err
+}
+
+// sweep drops a batch of expired keys, it's best effort since expired keys are taken over anyway.
+func (s *mysqlStore) sweep(ctx context.Context, now time.Time) {
+	s.mux.Lock()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		s.mux.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mux.Unlock()
+
+	_, _ = s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expire_at <= ? LIMIT ?", now, sweepBatch)
+}
@@ -0,0 +1,104 @@
+package idempotkit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInFlight indicates the key is reserved by another request which hasn't completed.
+	ErrInFlight = errors.New("idempotency key in flight")
+)
+
+// Store keeps the responses of requests by their idempotency keys.
+type Store interface {
+	// Reserve claims the key for the request until ttl passes, and returns nil if it's claimed.
+	// It returns the response saved by Complete() if the key has completed, or ErrInFlight if the key
+	// is reserved by another request.
+	Reserve(ctx context.Context, key string, ttl time.Duration) ([]byte, error)
+	// Complete saves the response of the reserved key, which is returned by Reserve() until ttl passes.
+	Complete(ctx context.Context, key string, resp []byte, ttl time.Duration) error
+	// Release drops the reservation of the key in flight, so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// sweepInterval is how often stores drop expired keys.
+const sweepInterval = time.Minute
+
+// NewMemoryStore returns a Store kept in the memory of the process, which isn't shared among pods.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: map[string]memoryEntry{},
+		now:     time.Now,
+	}
+}
+
+type memoryStore struct {
+	mux       sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryEntry struct {
+	// resp is nil while the request is in flight
+	resp     []byte
+	expireAt time.Time
+}
+
+func (s *memoryStore) Reserve(_ context.Context, key string, ttl time.Duration) ([]byte, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expireAt) {
+		if e.resp == nil {
+			return nil, ErrInFlight
+		}
+		return e.resp, nil
+	}
+	s.entries[key] = memoryEntry{expireAt: now.Add(ttl)}
+
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, key string, resp []byte, ttl time.Duration) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if resp == nil {
+		resp = []byte{}
+	}
+	s.entries[key] = memoryEntry{resp: resp, expireAt: s.now().Add(ttl)}
+
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, key string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.resp == nil {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+// sweep drops expired keys, which are usually unique per request and never seen again.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for k, e := range s.entries {
+		if !now.Before(e.expireAt) {
+			delete(s.entries, k)
+		}
+	}
+}
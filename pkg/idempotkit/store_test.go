@@ -0,0 +1,190 @@
+package idempotkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/suite"
+)
+
+type storeSuite struct {
+	suite.Suite
+
+	now time.Time
+}
+
+func (s *storeSuite) SetupSuite()    {}
+func (s *storeSuite) TearDownSuite() {}
+func (s *storeSuite) SetupTest() {
+	s.now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+func (s *storeSuite) TearDownTest() {}
+
+func TestStoreSuite(t *testing.T) {
+	suite.Run(t, new(storeSuite))
+}
+
+func (s *storeSuite) clock() time.Time {
+	return s.now
+}
+
+func (s *storeSuite) TestMemoryStore() {
+	type step struct {
+		Advance time.Duration
+		Do      func(Store) ([]byte, error)
+		ExpResp []byte
+		ExpErr  error
+	}
+	ctx := context.Background()
+	reserve := func(st Store) ([]byte, error) { return st.Reserve(ctx, "key", time.Minute) }
+	complete := func(st Store) ([]byte, error) { return nil, st.Complete(ctx, "key", []byte("resp"), time.Hour) }
+	release := func(st Store) ([]byte, error) { return nil, st.Release(ctx, "key") }
+
+	tests := []struct {
+		Desc  string
+		Steps []step
+	}{
+		{
+			Desc: "replay the completed response",
+			Steps: []step{
+				{Do: reserve},
+				{Do: reserve, ExpErr: ErrInFlight},
+				{Do: complete},
+				{Do: reserve, ExpResp: []byte("resp")},
+				// the completed response isn't released
+				{Do: release},
+				{Advance: 59 * time.Minute, Do: reserve, ExpResp: []byte("resp")},
+				{Advance: time.Minute, Do: reserve},
+			},
+		},
+		{
+			Desc: "retry the released key",
+			Steps: []step{
+				{Do: reserve},
+				{Do: release},
+				{Do: reserve},
+			},
+		},
+		{
+			Desc: "take over the key of the crashed request",
+			Steps: []step{
+				{Do: reserve},
+				{Advance: 30 * time.Second, Do: reserve, ExpErr: ErrInFlight},
+				{Advance: 30 * time.Second, Do: reserve},
+			},
+		},
+	}
+
+	for _, t := range tests {
+		st := NewMemoryStore()
+		st.(*memoryStore).now = s.clock
+		for i, step := range t.Steps {
+			s.now = s.now.Add(step.Advance)
+			resp, err := step.Do(st)
+			s.Require().ErrorIs(err, step.ExpErr, t.Desc, i)
+			s.Require().Equal(step.ExpResp, resp, t.Desc, i)
+		}
+	}
+}
+
+func (s *storeSuite) TestMySQLStoreReserve() {
+	const (
+		insert = "INSERT INTO idempotency_keys"
+		update = "UPDATE idempotency_keys SET response = NULL"
+		query  = "SELECT response FROM idempotency_keys"
+	)
+	dupErr := &mysql.MySQLError{Number: erDupEntry, Message: "Duplicate entry"}
+	dbErr := errors.New("connection refused")
+
+	tests := []struct {
+		Desc    string
+		Expect  func(sqlmock.Sqlmock)
+		ExpResp []byte
+		ExpErr  error
+	}{
+		{
+			Desc: "new key",
+			Expect: func(m sqlmock.Sqlmock) {
+				m.ExpectExec(insert).WithArgs("key", s.now.Add(time.Minute)).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			Desc: "expired key",
+			Expect: func(m sqlmock.Sqlmock) {
+				m.ExpectExec(insert).WillReturnError(dupErr)
+				m.ExpectExec(update).WithArgs(s.now.Add(time.Minute), "key", s.now).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			Desc: "in flight",
+			Expect: func(m sqlmock.Sqlmock) {
+				m.ExpectExec(insert).WillReturnError(dupErr)
+				m.ExpectExec(update).WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectQuery(query).WithArgs("key").
+					WillReturnRows(sqlmock.NewRows([]string{"response"}).AddRow(nil))
+			},
+			ExpErr: ErrInFlight,
+		},
+		{
+			Desc: "completed",
+			Expect: func(m sqlmock.Sqlmock) {
+				m.ExpectExec(insert).WillReturnError(dupErr)
+				m.ExpectExec(update).WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectQuery(query).WithArgs("key").
+					WillReturnRows(sqlmock.NewRows([]string{"response"}).AddRow([]byte("resp")))
+			},
+			ExpResp: []byte("resp"),
+		},
+		{
+			Desc: "db error",
+			Expect: func(m sqlmock.Sqlmock) {
+				m.ExpectExec(insert).WillReturnError(dbErr)
+			},
+			ExpErr: dbErr,
+		},
+	}
+
+	for _, t := range tests {
+		db, m, err := sqlmock.New()
+		s.Require().NoError(err, t.Desc)
+
+		st := NewMySQLStore(db)
+		// skip sweeping expired keys
+		st.(*mysqlStore).lastSweep = s.now
+		st.(*mysqlStore).now = s.clock
+		t.Expect(m)
+
+		resp, err := st.Reserve(context.Background(), "key", time.Minute)
+		s.Require().True(errors.Is(err, t.ExpErr), t.Desc, err)
+		s.Require().Equal(t.ExpResp, resp, t.Desc)
+		s.Require().NoError(m.ExpectationsWereMet(), t.Desc)
+		db.Close()
+	}
+}
+
+func (s *storeSuite) TestMySQLStoreSweep() {
+	db, m, err := sqlmock.New()
+	s.Require().NoError(err)
+	defer db.Close()
+
+	st := NewMySQLStore(db)
+	st.(*mysqlStore).now = s.clock
+
+	m.ExpectExec("DELETE FROM idempotency_keys WHERE expire_at <= ?").WithArgs(s.now, sweepBatch).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	m.ExpectExec("INSERT INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 1))
+	// swept once a minute only
+	m.ExpectExec("INSERT INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	for _, key := range []string{"a", "b"} {
+		resp, err := st.Reserve(context.Background(), key, time.Minute)
+		s.Require().NoError(err)
+		s.Require().Nil(resp)
+	}
+	s.Require().NoError(m.ExpectationsWereMet())
+}
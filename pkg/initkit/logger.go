@@ -2,8 +2,10 @@ package initkit
 
 import (
 	"demo/pkg/logger"
+	"demo/pkg/redactkit"
 	"os"
 	"strconv"
+	"strings"
 )
 
 func InitLogger() func() error {
@@ -15,6 +17,9 @@ func InitLogger() func() error {
 		Level:       logger.Level(level),
 	})
 
+	// mask the field paths in logs besides sensitive fields in proto files, e.g. LOGGER_REDACT_DENYLIST=token,card.number
+	redactkit.Register(redactkit.WithDenylist(strings.Split(os.Getenv("LOGGER_REDACT_DENYLIST"), ",")...))
+
 	logger.Info("Logger done", logger.WithFields(logger.Fields{
 		"level":       development,
 		"development": level,
@@ -5,13 +5,15 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/rafaelhl/gorm-newrelic-telemetry-plugin/telemetry"
 	"gorm.io/gorm"
 
+	"demo/pkg/bootkit"
 	"demo/pkg/envkit"
 	"demo/pkg/logger"
+	"demo/pkg/metrickit"
 	sqlMig "demo/pkg/migration/sql"
 	"demo/pkg/mysqlkit"
+	"demo/pkg/telemetrykit"
 )
 
 func NewGormDB() *gorm.DB {
@@ -31,11 +33,8 @@ func NewGormDB() *gorm.DB {
 
 	db, err := mysqlkit.NewGORM(
 		cfg.FormatDSN(),
-		telemetry.NewNrTracer(
-			cfg.DBName, // db name
-			cfg.Addr,   // Host is the name of the server hosting the datastore.
-			"MySQL",    // product name: fixed string defined by new relic
-		),
+		// traces queries with the provider set up by NewTelemetry()
+		telemetrykit.GormPlugin(telemetrykit.Default(), cfg.DBName, cfg.Addr),
 	)
 	if err != nil {
 		logger.Fatal("mysqlkit.NewGORM failed", logger.WithError(err))
@@ -48,6 +47,14 @@ func NewGormDB() *gorm.DB {
 	sqlDB.SetMaxOpenConns(int(maxOpenConns))
 	sqlDB.SetConnMaxLifetime(time.Duration(connMaxLifeMinutes) * time.Minute)
 
+	// export the stats of the pool on /metrics
+	if err := metrickit.RegisterDBStats(cfg.DBName, sqlDB); err != nil {
+		logger.Error("metrickit.RegisterDBStats failed", logger.WithError(err))
+	}
+
+	// mark the pod unready once mysql is unreachable
+	bootkit.AddProbe("mysql", sqlDB.PingContext, bootkit.WithProbeTimeout(2*time.Second))
+
 	logger.Info("GORM done", logger.WithFields(logger.Fields{
 		"max-open-conns":     maxOpenConns,
 		"conn-max-life-mins": connMaxLifeMinutes,
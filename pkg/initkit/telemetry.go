@@ -0,0 +1,55 @@
+package initkit
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"time"
+
+	"demo/pkg/bootkit"
+	"demo/pkg/logger"
+	"demo/pkg/telemetrykit"
+)
+
+// NewTelemetry sets up the telemetry provider selected by TELEMETRY_PROVIDER as the default one of telemetrykit:
+//
+//	newrelic  New Relic configured by NEW_RELIC_* environment variables, see NewNewRelicApp(), which is the default
+//	otel      OpenTelemetry exporting spans over OTLP gRPC, configured by OTEL_* environment variables,
+//	          ex: OTEL_EXPORTER_OTLP_ENDPOINT and OTEL_SERVICE_NAME
+//	noop      propagates W3C trace context without exporting
+//
+// It exits on an unknown provider, so a typo doesn't disable tracing silently.
+// Buffered spans are flushed on shutdown.
+func NewTelemetry() telemetrykit.Provider {
+	// deployments configured before TELEMETRY_PROVIDER keep using New Relic
+	name := cmp.Or(os.Getenv("TELEMETRY_PROVIDER"), "newrelic")
+	logger.Info("Telemetry preparing", logger.WithField("provider", name))
+
+	var p telemetrykit.Provider
+	switch name {
+	case "newrelic":
+		p = telemetrykit.NewNewRelic(NewNewRelicApp())
+	case "otel":
+		var err error
+		p, err = telemetrykit.NewOTel(context.Background())
+		if err != nil {
+			logger.Fatal("telemetrykit.NewOTel failed", logger.WithError(err))
+		}
+	case "noop":
+		p = telemetrykit.NewNoop()
+	default:
+		logger.Fatal("unknown telemetry provider", logger.WithField("provider", name))
+	}
+	telemetrykit.SetDefault(p)
+
+	// flush after servers are closed
+	bootkit.AddShutdownHandler(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return p.Shutdown(ctx)
+	}, bootkit.WithShutdownName("telemetry"), bootkit.WithShutdownLevel(1))
+
+	logger.Info("Telemetry done", logger.WithField("provider", name))
+
+	return p
+}
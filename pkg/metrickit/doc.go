@@ -0,0 +1,5 @@
+// Package metrickit collects metrics of the service and exposes them in Prometheus text format.
+// Request counts, latencies and in-flight gauges of gRPC methods and gateway routes are fed by servkit,
+// and sql.DBStats of database pools are registered by RegisterDBStats().
+// Custom metrics can be registered into Registerer(), which works without New Relic, ex: in local and CI.
+package metrickit
@@ -0,0 +1,37 @@
+package metrickit
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		grpcHandled, grpcHandling, grpcInFlight,
+		httpHandled, httpHandling, httpInFlight,
+	)
+}
+
+// Registerer returns the registry which custom metrics are registered into.
+func Registerer() prometheus.Registerer {
+	return registry
+}
+
+// Handler serves all registered metrics in Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// RegisterDBStats exports sql.DBStats of the pool, ex: the one of GORM from gorm.DB.DB(),
+// labeled by db_name. It fails if the name is registered already.
+func RegisterDBStats(name string, db *sql.DB) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, name))
+}
@@ -0,0 +1,97 @@
+package metrickit
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+)
+
+type metricSuite struct {
+	suite.Suite
+}
+
+func (s *metricSuite) SetupSuite()    {}
+func (s *metricSuite) TearDownSuite() {}
+func (s *metricSuite) SetupTest()     {}
+func (s *metricSuite) TearDownTest()  {}
+
+func TestMetricSuite(t *testing.T) {
+	suite.Run(t, new(metricSuite))
+}
+
+func (s *metricSuite) scrape() string {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	b, err := io.ReadAll(rec.Body)
+	s.Require().NoError(err)
+	return string(b)
+}
+
+func (s *metricSuite) TestRequests() {
+	StartGRPC("/example.Example/Login")(codes.InvalidArgument)
+	doneHTTP := StartHTTP(http.MethodGet)
+	doneUnknown := StartHTTP("FOOBAR")
+
+	tests := []struct {
+		Desc   string
+		Before func()
+		Exp    []string
+	}{
+		{
+			Desc: "in flight",
+			Exp: []string{
+				`grpc_server_handled_total{grpc_code="InvalidArgument",grpc_method="/example.Example/Login"} 1`,
+				`grpc_server_handling_seconds_count{grpc_method="/example.Example/Login"} 1`,
+				`grpc_server_in_flight{grpc_method="/example.Example/Login"} 0`,
+				`http_requests_in_flight 2`,
+			},
+		},
+		{
+			Desc:   "done",
+			Before: func() { doneHTTP("/v1/item", http.StatusOK) },
+			Exp: []string{
+				`http_requests_total{code="200",method="GET",route="/v1/item"} 1`,
+				`http_request_duration_seconds_count{method="GET",route="/v1/item"} 1`,
+				`http_requests_in_flight 1`,
+			},
+		},
+		{
+			Desc:   "unknown method",
+			Before: func() { doneUnknown("/v1/item", http.StatusMethodNotAllowed) },
+			Exp: []string{
+				`http_requests_total{code="405",method="other",route="/v1/item"} 1`,
+				`http_request_duration_seconds_count{method="other",route="/v1/item"} 1`,
+				`http_requests_in_flight 0`,
+			},
+		},
+	}
+
+	for _, t := range tests {
+		if t.Before != nil {
+			t.Before()
+		}
+		out := s.scrape()
+		for _, e := range t.Exp {
+			s.Require().Contains(out, e, t.Desc)
+		}
+		s.Require().NotContains(out, "FOOBAR", t.Desc)
+	}
+}
+
+func (s *metricSuite) TestRegisterDBStats() {
+	db, err := sql.Open("mysql", "user:pass@tcp(127.0.0.1:3306)/demo")
+	s.Require().NoError(err)
+	defer db.Close()
+
+	s.Require().NoError(RegisterDBStats("demo", db))
+	s.Require().Error(RegisterDBStats("demo", db))
+	s.Require().Contains(s.scrape(), `go_sql_max_open_connections{db_name="demo"} 0`)
+}
@@ -0,0 +1,85 @@
+package metrickit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	grpcHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "Total number of RPCs completed on the server, regardless of success or failure.",
+	}, []string{"grpc_method", "grpc_code"})
+	grpcHandling = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Latency of RPCs handled by the server.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"grpc_method"})
+	grpcInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_server_in_flight",
+		Help: "Number of RPCs being handled by the server.",
+	}, []string{"grpc_method"})
+
+	httpHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests completed by the gateway.",
+	}, []string{"method", "route", "code"})
+	httpHandling = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests handled by the gateway.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests being handled by the gateway.",
+	})
+
+	// httpMethods are the standard methods of RFC 9110 and PATCH, others are labeled "other".
+	httpMethods = map[string]struct{}{
+		http.MethodGet:     {},
+		http.MethodHead:    {},
+		http.MethodPost:    {},
+		http.MethodPut:     {},
+		http.MethodPatch:   {},
+		http.MethodDelete:  {},
+		http.MethodConnect: {},
+		http.MethodOptions: {},
+		http.MethodTrace:   {},
+	}
+)
+
+// StartGRPC counts the RPC of the full method in flight,
+// and returns the function observing its status code and latency once it's done.
+func StartGRPC(method string) func(code codes.Code) {
+	start := time.Now()
+	inFlight := grpcInFlight.WithLabelValues(method)
+	inFlight.Inc()
+
+	return func(code codes.Code) {
+		inFlight.Dec()
+		grpcHandled.WithLabelValues(method, code.String()).Inc()
+		grpcHandling.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// StartHTTP counts the HTTP request in flight, and returns the function observing its route pattern,
+// ex: "/v1/item/{id}" rather than the path, status code and latency once it's done.
+// Methods out of the standard ones are labeled "other", since clients can send arbitrary methods.
+func StartHTTP(method string) func(route string, code int) {
+	if _, ok := httpMethods[method]; !ok {
+		method = "other"
+	}
+
+	start := time.Now()
+	httpInFlight.Inc()
+
+	return func(route string, code int) {
+		httpInFlight.Dec()
+		httpHandled.WithLabelValues(method, route, strconv.Itoa(code)).Inc()
+		httpHandling.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,4 @@
+// Package ratekit limits how often a key, ex: a client IP or a gRPC method, can do something.
+// Limiters are based on the token bucket or the sliding window algorithm, and keep their state in a Store,
+// which is in memory by default. A shared Store, ex: Redis or MySQL, makes pods enforce the limits together.
+package ratekit
@@ -0,0 +1,192 @@
+package ratekit
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	// ErrInvalidRate indicates the limit or the period of the rate isn't positive.
+	ErrInvalidRate = errors.New("invalid rate")
+	// ErrInvalidState indicates the state in the store isn't written by the limiter.
+	ErrInvalidState = errors.New("invalid limiter state")
+)
+
+// Rate is the number of requests allowed per period, ex: Rate{Limit: 10, Period: time.Minute}.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// Result is the outcome of Limiter.Allow().
+type Result struct {
+	Allowed bool
+	// Limit is the number of requests allowed per period.
+	Limit int
+	// Remaining is the number of requests still allowed right now.
+	Remaining int
+	// ResetAfter is how long it takes until the limit is fully available again.
+	ResetAfter time.Duration
+	// RetryAfter is how long it takes until the next request is allowed, it's zero if Allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter decides whether a request of the key is allowed, the request is counted if so.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// NewTokenBucket returns a Limiter refilling rate.Limit tokens evenly over rate.Period into a bucket of key,
+// and each request takes a token. The capacity of the bucket is set by WithBurst().
+func NewTokenBucket(rate Rate, options ...LimitOptions) (Limiter, error) {
+	if rate.Limit <= 0 || rate.Period <= 0 {
+		return nil, ErrInvalidRate
+	}
+	o := applyLimitOptions(rate, options...)
+	if o.burst <= 0 {
+		return nil, ErrInvalidRate
+	}
+
+	return &tokenBucket{rate: rate, opts: o}, nil
+}
+
+type tokenBucket struct {
+	rate Rate
+	opts *limitOptions
+}
+
+func (b *tokenBucket) Allow(ctx context.Context, key string) (Result, error) {
+	var ret Result
+	burst := float64(b.opts.burst)
+	// tokens refilled per nanosecond
+	perNano := float64(b.rate.Limit) / float64(b.rate.Period)
+	// the bucket is full again once the state expires
+	ttl := time.Duration(math.Ceil(burst / perNano))
+
+	err := b.opts.store.Update(ctx, key, ttl, func(state []byte) ([]byte, error) {
+		now := b.opts.now().UnixNano()
+		tokens, last := burst, now
+		if state != nil {
+			vals, err := decodeState(state, 2)
+			if err != nil {
+				return nil, err
+			}
+			tokens, last = math.Float64frombits(uint64(vals[0])), vals[1]
+		}
+
+		if elapsed := now - last; elapsed > 0 {
+			tokens = math.Min(burst, tokens+float64(elapsed)*perNano)
+		}
+
+		ret = Result{Limit: b.opts.burst}
+		if tokens >= 1 {
+			tokens--
+			ret.Allowed = true
+		} else {
+			ret.RetryAfter = time.Duration(math.Ceil((1 - tokens) / perNano))
+		}
+		ret.Remaining = int(tokens)
+		ret.ResetAfter = time.Duration(math.Ceil((burst - tokens) / perNano))
+
+		return encodeState(int64(math.Float64bits(tokens)), now), nil
+	})
+
+	return ret, err
+}
+
+// NewSlidingWindow returns a Limiter allowing rate.Limit requests of key in any window of rate.Period.
+// The count of the sliding window is estimated by weighting the count of the previous fixed window
+// with its overlap, so it only keeps two counters per key.
+func NewSlidingWindow(rate Rate, options ...LimitOptions) (Limiter, error) {
+	if rate.Limit <= 0 || rate.Period <= 0 {
+		return nil, ErrInvalidRate
+	}
+
+	return &slidingWindow{rate: rate, opts: applyLimitOptions(rate, options...)}, nil
+}
+
+type slidingWindow struct {
+	rate Rate
+	opts *limitOptions
+}
+
+func (w *slidingWindow) Allow(ctx context.Context, key string) (Result, error) {
+	var ret Result
+	period := int64(w.rate.Period)
+	limit := float64(w.rate.Limit)
+
+	err := w.opts.store.Update(ctx, key, 2*w.rate.Period, func(state []byte) ([]byte, error) {
+		now := w.opts.now().UnixNano()
+		start := now - now%period
+		var prev, curr int64
+		if state != nil {
+			vals, err := decodeState(state, 3)
+			if err != nil {
+				return nil, err
+			}
+			switch vals[0] {
+			case start:
+				prev, curr = vals[1], vals[2]
+			case start - period:
+				prev = vals[2]
+			}
+		}
+
+		elapsed := now - start
+		weight := 1 - float64(elapsed)/float64(period)
+		count := float64(prev)*weight + float64(curr)
+
+		ret = Result{Limit: w.rate.Limit, ResetAfter: time.Duration(period - elapsed)}
+		if count+1 <= limit {
+			curr++
+			count++
+			ret.Allowed = true
+		} else {
+			ret.RetryAfter = w.retryAfter(prev, curr, elapsed)
+		}
+		ret.Remaining = int(math.Max(0, math.Floor(limit-count)))
+
+		return encodeState(start, prev, curr), nil
+	})
+
+	return ret, err
+}
+
+// retryAfter returns how long it takes until the weighted count leaves room for a request.
+func (w *slidingWindow) retryAfter(prev, curr, elapsed int64) time.Duration {
+	period := float64(w.rate.Period)
+	room := float64(w.rate.Limit) - float64(curr) - 1
+	if room < 0 || prev == 0 {
+		// wait for the next window, where the current count is weighted instead
+		return time.Duration(period) - time.Duration(elapsed)
+	}
+
+	// prev * (1 - (elapsed+t)/period) <= room
+	t := period*(1-room/float64(prev)) - float64(elapsed)
+	return time.Duration(math.Max(1, math.Ceil(t)))
+}
+
+func encodeState(vals ...int64) []byte {
+	b := make([]byte, 8*len(vals))
+	for i, v := range vals {
+		binary.BigEndian.PutUint64(b[8*i:], uint64(v))
+	}
+
+	return b
+}
+
+func decodeState(b []byte, n int) ([]int64, error) {
+	if len(b) != 8*n {
+		return nil, ErrInvalidState
+	}
+
+	vals := make([]int64, n)
+	for i := range vals {
+		vals[i] = int64(binary.BigEndian.Uint64(b[8*i:]))
+	}
+
+	return vals, nil
+}
@@ -0,0 +1,157 @@
+package ratekit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type limiterSuite struct {
+	suite.Suite
+
+	now time.Time
+}
+
+func (s *limiterSuite) SetupSuite()    {}
+func (s *limiterSuite) TearDownSuite() {}
+func (s *limiterSuite) SetupTest() {
+	s.now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+func (s *limiterSuite) TearDownTest() {}
+
+func TestLimiterSuite(t *testing.T) {
+	suite.Run(t, new(limiterSuite))
+}
+
+func (s *limiterSuite) clock() time.Time {
+	return s.now
+}
+
+type step struct {
+	Advance    time.Duration
+	Key        string
+	ExpAllowed bool
+	ExpRemain  int
+	ExpRetry   time.Duration
+}
+
+func (s *limiterSuite) run(desc string, l Limiter, steps []step) {
+	for i, st := range steps {
+		s.now = s.now.Add(st.Advance)
+		key := st.Key
+		if key == "" {
+			key = "client"
+		}
+		ret, err := l.Allow(context.Background(), key)
+		s.Require().NoError(err, desc, i)
+		s.Require().Equal(st.ExpAllowed, ret.Allowed, desc, i)
+		s.Require().Equal(st.ExpRemain, ret.Remaining, desc, i)
+		s.Require().Equal(st.ExpRetry, ret.RetryAfter, desc, i)
+	}
+}
+
+func (s *limiterSuite) TestTokenBucket() {
+	tests := []struct {
+		Desc    string
+		Rate    Rate
+		Options []LimitOptions
+		Steps   []step
+	}{
+		{
+			Desc: "refill evenly",
+			Rate: Rate{Limit: 2, Period: time.Second},
+			Steps: []step{
+				{ExpAllowed: true, ExpRemain: 1},
+				{ExpAllowed: true, ExpRemain: 0},
+				{ExpAllowed: false, ExpRemain: 0, ExpRetry: 500 * time.Millisecond},
+				{Key: "other", ExpAllowed: true, ExpRemain: 1},
+				{Advance: 500 * time.Millisecond, ExpAllowed: true, ExpRemain: 0},
+				{Advance: 10 * time.Second, ExpAllowed: true, ExpRemain: 1},
+			},
+		},
+		{
+			Desc:    "burst",
+			Rate:    Rate{Limit: 1, Period: time.Second},
+			Options: []LimitOptions{WithBurst(3)},
+			Steps: []step{
+				{ExpAllowed: true, ExpRemain: 2},
+				{ExpAllowed: true, ExpRemain: 1},
+				{ExpAllowed: true, ExpRemain: 0},
+				{ExpAllowed: false, ExpRemain: 0, ExpRetry: time.Second},
+			},
+		},
+	}
+
+	for _, t := range tests {
+		s.SetupTest()
+		l, err := NewTokenBucket(t.Rate, t.Options...)
+		s.Require().NoError(err, t.Desc)
+		l.(*tokenBucket).opts.now = s.clock
+		s.run(t.Desc, l, t.Steps)
+	}
+}
+
+func (s *limiterSuite) TestSlidingWindow() {
+	tests := []struct {
+		Desc  string
+		Rate  Rate
+		Steps []step
+	}{
+		{
+			Desc: "weighted previous window",
+			Rate: Rate{Limit: 4, Period: time.Second},
+			Steps: []step{
+				{ExpAllowed: true, ExpRemain: 3},
+				{ExpAllowed: true, ExpRemain: 2},
+				{ExpAllowed: true, ExpRemain: 1},
+				{ExpAllowed: true, ExpRemain: 0},
+				{ExpAllowed: false, ExpRemain: 0, ExpRetry: time.Second},
+				// 4 * 0.5 from the previous window
+				{Advance: 1500 * time.Millisecond, ExpAllowed: true, ExpRemain: 1},
+				{ExpAllowed: true, ExpRemain: 0},
+				{ExpAllowed: false, ExpRemain: 0, ExpRetry: 250 * time.Millisecond},
+				{Advance: 250 * time.Millisecond, ExpAllowed: true, ExpRemain: 0},
+			},
+		},
+		{
+			Desc: "expired windows",
+			Rate: Rate{Limit: 1, Period: time.Second},
+			Steps: []step{
+				{ExpAllowed: true, ExpRemain: 0},
+				{Advance: 2 * time.Second, ExpAllowed: true, ExpRemain: 0},
+				{Key: "other", ExpAllowed: true, ExpRemain: 0},
+			},
+		},
+	}
+
+	for _, t := range tests {
+		s.SetupTest()
+		l, err := NewSlidingWindow(t.Rate)
+		s.Require().NoError(err, t.Desc)
+		l.(*slidingWindow).opts.now = s.clock
+		s.run(t.Desc, l, t.Steps)
+	}
+}
+
+func (s *limiterSuite) TestInvalidRate() {
+	_, err := NewTokenBucket(Rate{Limit: 0, Period: time.Second})
+	s.Require().ErrorIs(err, ErrInvalidRate)
+	_, err = NewSlidingWindow(Rate{Limit: 1})
+	s.Require().ErrorIs(err, ErrInvalidRate)
+}
+
+func (s *limiterSuite) TestMemoryStoreExpire() {
+	st := NewMemoryStore().(*memoryStore)
+	st.now = s.clock
+
+	inc := func(state []byte) ([]byte, error) { return append(state, 1), nil }
+	s.Require().NoError(st.Update(context.Background(), "key", time.Second, inc))
+	s.Require().NoError(st.Update(context.Background(), "key", time.Second, inc))
+	s.Require().Len(st.entries["key"].state, 2)
+
+	s.now = s.now.Add(2 * sweepInterval)
+	s.Require().NoError(st.Update(context.Background(), "other", time.Second, inc))
+	s.Require().NotContains(st.entries, "key")
+}
@@ -0,0 +1,60 @@
+package ratekit
+
+import "time"
+
+// LimitOptions sets the limiter options.
+type LimitOptions interface {
+	apply(*limitOptions)
+}
+
+type limitOptions struct {
+	store Store
+	burst int
+	now   func() time.Time
+}
+
+// funcLimitOption wraps a function that modifies limitOptions into an
+// implementation of the LimitOptions interface.
+type funcLimitOption struct {
+	f func(*limitOptions)
+}
+
+func (o *funcLimitOption) apply(do *limitOptions) {
+	o.f(do)
+}
+
+func newFuncLimitOption(f func(*limitOptions)) *funcLimitOption {
+	return &funcLimitOption{
+		f: f,
+	}
+}
+
+// WithStore specifies the store keeping the state of the limiter, it's a dedicated NewMemoryStore() by default.
+func WithStore(store Store) LimitOptions {
+	return newFuncLimitOption(func(opts *limitOptions) {
+		opts.store = store
+	})
+}
+
+// WithBurst specifies the capacity of the token bucket, it's the limit of the rate by default.
+// It's ignored by the sliding window.
+func WithBurst(burst int) LimitOptions {
+	return newFuncLimitOption(func(opts *limitOptions) {
+		opts.burst = burst
+	})
+}
+
+func applyLimitOptions(rate Rate, options ...LimitOptions) *limitOptions {
+	opts := &limitOptions{
+		burst: rate.Limit,
+		now:   time.Now,
+	}
+	for _, o := range options {
+		o.apply(opts)
+	}
+	if opts.store == nil {
+		opts.store = NewMemoryStore()
+	}
+
+	return opts
+}
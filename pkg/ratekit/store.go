@@ -0,0 +1,73 @@
+package ratekit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps the state of limiters by key.
+type Store interface {
+	// Update atomically replaces the state of the key with the one returned by fn, which receives nil
+	// if the key doesn't exist or has expired. The new state expires after ttl.
+	// The state isn't changed if fn returns an error.
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(state []byte) ([]byte, error)) error
+}
+
+// sweepInterval is how often the memory store drops expired keys.
+const sweepInterval = time.Minute
+
+// NewMemoryStore returns a Store kept in the memory of the process, which isn't shared among pods.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: map[string]memoryEntry{},
+		now:     time.Now,
+	}
+}
+
+type memoryStore struct {
+	mux       sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryEntry struct {
+	state    []byte
+	expireAt time.Time
+}
+
+func (s *memoryStore) Update(_ context.Context, key string, ttl time.Duration, fn func([]byte) ([]byte, error)) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	var state []byte
+	if e, ok := s.entries[key]; ok && now.Before(e.expireAt) {
+		state = e.state
+	}
+
+	state, err := fn(state)
+	if err != nil {
+		return err
+	}
+	s.entries[key] = memoryEntry{state: state, expireAt: now.Add(ttl)}
+
+	return nil
+}
+
+// sweep drops expired keys, so keys seen once, ex: client IPs, don't pile up.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for k, e := range s.entries {
+		if !now.Before(e.expireAt) {
+			delete(s.entries, k)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package redactkit masks sensitive data before it's logged.
+// Fields annotated with `(redact.sensitive) = true` in proto files are masked, along with
+// the field paths in the denylist, ex: "password" at any depth or "credentials.token" from the root.
+// The same rules apply to proto messages and raw request bodies.
+package redactkit
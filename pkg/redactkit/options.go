@@ -0,0 +1,55 @@
+package redactkit
+
+// RedactOptions sets the redactor options.
+type RedactOptions interface {
+	apply(*redactOptions)
+}
+
+type redactOptions struct {
+	denylist []string
+	mask     string
+}
+
+// funcRedactOption wraps a function that modifies redactOptions into an
+// implementation of the RedactOptions interface.
+type funcRedactOption struct {
+	f func(*redactOptions)
+}
+
+func (o *funcRedactOption) apply(do *redactOptions) {
+	o.f(do)
+}
+
+func newFuncRedactOption(f func(*redactOptions)) *funcRedactOption {
+	return &funcRedactOption{
+		f: f,
+	}
+}
+
+// WithDenylist specifies field paths masked in addition to sensitive fields, which are case-insensitive.
+// A name without dots matches the field at any depth, ex: "password",
+// while a dotted path matches from the root, ex: "credentials.token".
+func WithDenylist(paths ...string) RedactOptions {
+	return newFuncRedactOption(func(opts *redactOptions) {
+		opts.denylist = append(opts.denylist, paths...)
+	})
+}
+
+// WithMask specifies the placeholder of masked values, it's "[REDACTED]" by default.
+func WithMask(mask string) RedactOptions {
+	return newFuncRedactOption(func(opts *redactOptions) {
+		opts.mask = mask
+	})
+}
+
+func applyRedactOptions(options ...RedactOptions) *redactOptions {
+	opts := &redactOptions{
+		denylist: []string{},
+		mask:     defaultMask,
+	}
+	for _, o := range options {
+		o.apply(opts)
+	}
+
+	return opts
+}
@@ -0,0 +1,271 @@
+package redactkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"demo/proto/redact"
+)
+
+const (
+	defaultMask = "[REDACTED]"
+)
+
+var (
+	defaultRedactor atomic.Pointer[Redactor]
+
+	// names of sensitive fields in all registered proto files, which are used to mask raw bodies.
+	sensitiveNames     map[string]struct{}
+	sensitiveNamesOnce sync.Once
+)
+
+func init() {
+	defaultRedactor.Store(New())
+}
+
+// Redactor masks sensitive fields and the ones in the denylist.
+type Redactor struct {
+	// denylist keeps lowercase field paths split by dots.
+	denylist [][]string
+	mask     string
+}
+
+// New returns a Redactor.
+func New(options ...RedactOptions) *Redactor {
+	o := applyRedactOptions(options...)
+
+	r := &Redactor{
+		denylist: [][]string{},
+		mask:     o.mask,
+	}
+	for _, p := range o.denylist {
+		if p = strings.TrimSpace(p); p != "" {
+			r.denylist = append(r.denylist, strings.Split(strings.ToLower(p), "."))
+		}
+	}
+
+	return r
+}
+
+// Register replaces the default Redactor used by Message() and Body().
+func Register(options ...RedactOptions) {
+	defaultRedactor.Store(New(options...))
+}
+
+// Default returns the default Redactor.
+func Default() *Redactor {
+	return defaultRedactor.Load()
+}
+
+// Message returns the JSON representation of the message with masked fields by the default Redactor.
+func Message(m interface{}) string {
+	return Default().Message(m)
+}
+
+// Body returns the request body with masked fields by the default Redactor.
+func Body(contentType string, body []byte) string {
+	return Default().Body(contentType, body)
+}
+
+// Message returns the JSON representation of the message with masked fields.
+// Fields of proto messages are masked based on their `sensitive` option and the denylist,
+// other values are masked based on the denylist and names of sensitive fields.
+func (r *Redactor) Message(m interface{}) string {
+	var md protoreflect.MessageDescriptor
+	var b []byte
+	var err error
+	if pm, ok := m.(proto.Message); ok {
+		md = pm.ProtoReflect().Descriptor()
+		b, err = protojson.Marshal(pm)
+	} else {
+		b, err = json.Marshal(m)
+	}
+	if err != nil {
+		return r.mask
+	}
+
+	return r.json(b, md)
+}
+
+// Body returns the request body with masked fields based on the denylist and names of sensitive fields.
+// JSON and form bodies are masked field by field, others are masked entirely since fields can't be told apart.
+func (r *Redactor) Body(contentType string, body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return string(body)
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return r.mask
+		}
+		for k := range values {
+			if r.masked([]string{k}, nil) {
+				values[k] = []string{r.mask}
+			}
+		}
+		return values.Encode()
+	}
+
+	if json.Valid(body) {
+		return r.json(body, nil)
+	}
+
+	return fmt.Sprintf("%s (%d bytes)", r.mask, len(body))
+}
+
+func (r *Redactor) json(b []byte, md protoreflect.MessageDescriptor) string {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return r.mask
+	}
+
+	ret, err := json.Marshal(r.walk(v, md, nil))
+	if err != nil {
+		return r.mask
+	}
+
+	return string(ret)
+}
+
+// walk masks values in v. md describes v if it's a proto message, otherwise it's nil.
+func (r *Redactor) walk(v interface{}, md protoreflect.MessageDescriptor, path []string) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = r.walk(v[i], md, path)
+		}
+	case map[string]interface{}:
+		for k, val := range v {
+			p := append(path[:len(path):len(path)], k)
+
+			fd := field(md, k)
+			if r.masked(p, fd) {
+				v[k] = r.mask
+				continue
+			}
+
+			switch {
+			case fd != nil && fd.IsMap():
+				// keys of maps are not field names
+				if obj, ok := val.(map[string]interface{}); ok {
+					for mk := range obj {
+						obj[mk] = r.walk(obj[mk], message(fd.MapValue()), p)
+					}
+				}
+			default:
+				v[k] = r.walk(val, message(fd), p)
+			}
+		}
+	}
+
+	return v
+}
+
+// masked checks whether the field at the path should be masked.
+func (r *Redactor) masked(path []string, fd protoreflect.FieldDescriptor) bool {
+	if fd != nil {
+		if s, ok := proto.GetExtension(fd.Options(), redact.E_Sensitive).(bool); ok && s {
+			return true
+		}
+	} else if _, ok := sensitiveFieldNames()[strings.ToLower(path[len(path)-1])]; ok {
+		// raw values don't have descriptors, match by names of sensitive fields instead.
+		return true
+	}
+
+	for _, deny := range r.denylist {
+		if matchPath(path, deny) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPath matches a single name at any depth, or a dotted path from the root.
+func matchPath(path, deny []string) bool {
+	if len(deny) == 1 {
+		return strings.EqualFold(path[len(path)-1], deny[0])
+	}
+
+	if len(path) != len(deny) {
+		return false
+	}
+	for i := range path {
+		if !strings.EqualFold(path[i], deny[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// field looks up the field of the message by its JSON name or proto name.
+func field(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	if md == nil {
+		return nil
+	}
+
+	if fd := md.Fields().ByJSONName(name); fd != nil {
+		return fd
+	}
+
+	return md.Fields().ByTextName(name)
+}
+
+// message returns the descriptor of the message field, or nil if it isn't a message
+// or it's a well-known type whose JSON representation isn't an object of fields.
+func message(fd protoreflect.FieldDescriptor) protoreflect.MessageDescriptor {
+	if fd == nil || fd.Message() == nil {
+		return nil
+	}
+
+	md := fd.Message()
+	if md.ParentFile().Package() == "google.protobuf" {
+		return nil
+	}
+
+	return md
+}
+
+// sensitiveFieldNames collects names of sensitive fields in all registered proto files.
+func sensitiveFieldNames() map[string]struct{} {
+	sensitiveNamesOnce.Do(func() {
+		sensitiveNames = map[string]struct{}{}
+
+		var walk func(ms protoreflect.MessageDescriptors)
+		walk = func(ms protoreflect.MessageDescriptors) {
+			for i := 0; i < ms.Len(); i++ {
+				md := ms.Get(i)
+				for j := 0; j < md.Fields().Len(); j++ {
+					fd := md.Fields().Get(j)
+					if s, ok := proto.GetExtension(fd.Options(), redact.E_Sensitive).(bool); ok && s {
+						sensitiveNames[strings.ToLower(fd.JSONName())] = struct{}{}
+						sensitiveNames[strings.ToLower(string(fd.Name()))] = struct{}{}
+					}
+				}
+				walk(md.Messages())
+			}
+		}
+
+		protoregistry.GlobalFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+			walk(fd.Messages())
+			return true
+		})
+	})
+
+	return sensitiveNames
+}
@@ -0,0 +1,124 @@
+package redactkit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	examplePb "demo/proto/example"
+)
+
+type redactSuite struct {
+	suite.Suite
+}
+
+func (s *redactSuite) SetupSuite()    {}
+func (s *redactSuite) TearDownSuite() {}
+func (s *redactSuite) SetupTest()     {}
+func (s *redactSuite) TearDownTest()  {}
+
+func TestRedactSuite(t *testing.T) {
+	suite.Run(t, new(redactSuite))
+}
+
+func (s *redactSuite) TestMessage() {
+	tests := []struct {
+		Desc    string
+		Options []RedactOptions
+		Msg     interface{}
+		ExpRet  string
+	}{
+		{
+			Desc:   "sensitive field",
+			Msg:    &examplePb.LoginReq{Username: "leo", Password: "p@ssw0rd"},
+			ExpRet: `{"Password":"[REDACTED]","Username":"leo"}`,
+		},
+		{
+			Desc:    "denylist at any depth",
+			Options: []RedactOptions{WithDenylist("ITEM_NAME")},
+			Msg: &examplePb.ListItemsResp{Status: "ok", Item: []*examplePb.ItemData{
+				{ItemId: 1, ItemName: "apple", Category: "fruit"},
+				{ItemId: 2, ItemName: "beef", Category: "meat"},
+			}},
+			ExpRet: `{"item":[{"category":"fruit","item_name":"[REDACTED]","itemid":"1"},{"category":"meat","item_name":"[REDACTED]","itemid":"2"}],"status":"ok"}`,
+		},
+		{
+			Desc:    "denylist from the root",
+			Options: []RedactOptions{WithDenylist("Item.Category")},
+			Msg:     &examplePb.ListItemsResp{Status: "ok", Item: []*examplePb.ItemData{{ItemId: 1, Category: "fruit"}}},
+			ExpRet:  `{"item":[{"category":"[REDACTED]","itemid":"1"}],"status":"ok"}`,
+		},
+		{
+			Desc:    "dotted path doesn't match other depths",
+			Options: []RedactOptions{WithDenylist("category.item")},
+			Msg:     &examplePb.ListItemsResp{Status: "ok", Item: []*examplePb.ItemData{{ItemId: 1, Category: "fruit"}}},
+			ExpRet:  `{"item":[{"category":"fruit","itemid":"1"}],"status":"ok"}`,
+		},
+		{
+			Desc:    "customized mask",
+			Options: []RedactOptions{WithMask("***")},
+			Msg:     &examplePb.LoginReq{Username: "leo", Password: "p@ssw0rd"},
+			ExpRet:  `{"Password":"***","Username":"leo"}`,
+		},
+		{
+			Desc:    "non-proto value",
+			Options: []RedactOptions{WithDenylist("token")},
+			Msg: map[string]interface{}{
+				"token":    "abc",
+				"password": "p@ssw0rd",
+				"user":     map[string]interface{}{"name": "leo"},
+			},
+			ExpRet: `{"password":"[REDACTED]","token":"[REDACTED]","user":{"name":"leo"}}`,
+		},
+	}
+
+	for _, t := range tests {
+		r := New(t.Options...)
+		s.Require().JSONEq(t.ExpRet, r.Message(t.Msg), t.Desc)
+	}
+}
+
+func (s *redactSuite) TestBody() {
+	tests := []struct {
+		Desc        string
+		ContentType string
+		Body        string
+		ExpRet      string
+	}{
+		{
+			Desc:        "empty",
+			ContentType: "application/json",
+			Body:        "",
+			ExpRet:      "",
+		},
+		{
+			Desc:        "json",
+			ContentType: "application/json",
+			Body:        `{"Username":"leo","Password":"p@ssw0rd","profile":{"secret":1.50}}`,
+			ExpRet:      `{"Password":"[REDACTED]","Username":"leo","profile":{"secret":"[REDACTED]"}}`,
+		},
+		{
+			Desc:        "json array",
+			ContentType: "application/json",
+			Body:        `[{"password":"p@ssw0rd"}]`,
+			ExpRet:      `[{"password":"[REDACTED]"}]`,
+		},
+		{
+			Desc:        "form",
+			ContentType: "application/x-www-form-urlencoded; charset=utf-8",
+			Body:        "Username=leo&Password=p%40ssw0rd",
+			ExpRet:      "Password=%5BREDACTED%5D&Username=leo",
+		},
+		{
+			Desc:        "unknown format",
+			ContentType: "text/plain",
+			Body:        "Password: p@ssw0rd",
+			ExpRet:      "[REDACTED] (18 bytes)",
+		},
+	}
+
+	r := New(WithDenylist("secret"))
+	for _, t := range tests {
+		s.Require().Equal(t.ExpRet, r.Body(t.ContentType, []byte(t.Body)), t.Desc)
+	}
+}
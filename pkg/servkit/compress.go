@@ -0,0 +1,300 @@
+package servkit
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"demo/pkg/logger"
+)
+
+const (
+	// defaultCompressMinSize skips compressing bodies smaller than 1KiB, which barely shrink.
+	defaultCompressMinSize = 1024
+	// brotliLevel trades the ratio for speed, brotli in pure Go is slow at the default level.
+	brotliLevel = 4
+)
+
+// encoder compresses the response, which is reused across responses by Reset().
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encodings are the supported content codings in order of preference if clients accept them equally.
+var encodings = []struct {
+	name string
+	pool *sync.Pool
+}{
+	{"zstd", &sync.Pool{New: func() interface{} {
+		// the concurrency of 1 keeps encoders cheap, which are pooled per response
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedFastest))
+		return e
+	}}},
+	{"br", &sync.Pool{New: func() interface{} {
+		return brotli.NewWriterLevel(nil, brotliLevel)
+	}}},
+	{"gzip", &sync.Pool{New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}}},
+	{"deflate", &sync.Pool{New: func() interface{} {
+		w, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return w
+	}}},
+}
+
+// compressedTypes are content types compressed already, which barely shrink.
+var compressedTypes = map[string]struct{}{
+	"application/gzip":             {},
+	"application/x-gzip":           {},
+	"application/zip":              {},
+	"application/zstd":             {},
+	"application/x-bzip2":          {},
+	"application/x-xz":             {},
+	"application/x-7z-compressed":  {},
+	"application/x-rar-compressed": {},
+	"application/vnd.rar":          {},
+	"font/woff":                    {},
+	"font/woff2":                   {},
+}
+
+// compressible reports whether the content type is worth compressing.
+func compressible(contentType string) bool {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+
+	if _, ok := compressedTypes[t]; ok {
+		return false
+	}
+	switch {
+	case t == "image/svg+xml":
+		return true
+	case strings.HasPrefix(t, "image/"), strings.HasPrefix(t, "video/"), strings.HasPrefix(t, "audio/"):
+		return false
+	}
+
+	return true
+}
+
+// negotiateEncoding picks the encoding accepted with the highest quality, see RFC 9110 section 12.5.3.
+// It returns -1 if none of encodings is acceptable.
+func negotiateEncoding(accept string) int {
+	qs := make([]float64, len(encodings))
+	for i := range qs {
+		qs[i] = -1
+	}
+	wildcard := -1.0
+
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		for i, e := range encodings {
+			if e.name == name {
+				qs[i] = q
+			}
+		}
+	}
+
+	best, bestQ := -1, 0.0
+	for i, q := range qs {
+		if q < 0 {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+
+	return best
+}
+
+// compressMiddleware compresses responses of the gateway with the encoding negotiated by Accept-Encoding.
+// Bodies smaller than minSize, and content types compressed already are sent as they are.
+// Streaming responses, which flush before reaching minSize, are compressed and flushed message by message.
+func compressMiddleware(minSize int) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			enc := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if enc < 0 || r.Method == http.MethodHead {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: enc, minSize: minSize, code: http.StatusOK}
+			defer cw.close()
+
+			h.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// compressWriter buffers the body until it's large enough to compress, or the handler flushes.
+type compressWriter struct {
+	http.ResponseWriter
+
+	encoding int
+	minSize  int
+
+	code        int
+	wroteHeader bool // WriteHeader() is called by the handler
+	decided     bool // the header is sent, either compressed or not
+	buf         []byte
+	enc         encoder
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	// informational responses are sent right away, and the final one follows
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.wroteHeader {
+		return
+	}
+
+	w.code = code
+	w.wroteHeader = true
+	if !bodyAllowed(code) {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends the buffered body, streaming responses of the gateway are compressed regardless of the size.
+func (w *compressWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide sends the header, compressing the body if wanted and the response is eligible,
+// then writes the buffered body.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+
+	hdr := w.Header()
+	if hdr.Get("Content-Type") == "" && len(w.buf) > 0 {
+		// sniff before compressing as net/http does, which can't sniff compressed bodies
+		hdr.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if compress && hdr.Get("Content-Encoding") == "" && compressible(hdr.Get("Content-Type")) &&
+		!strings.Contains(hdr.Get("Cache-Control"), "no-transform") {
+		e := encodings[w.encoding]
+		hdr.Set("Content-Encoding", e.name)
+		hdr.Del("Content-Length")
+		w.enc = e.pool.Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close sends the body smaller than minSize as it is, or finishes the compressed one.
+func (w *compressWriter) close() {
+	if !w.decided {
+		if !w.wroteHeader {
+			// nothing is written by the handler, leave the response to net/http
+			return
+		}
+		if err := w.decide(false); err != nil {
+			return
+		}
+	}
+	if w.enc == nil {
+		return
+	}
+
+	if err := w.enc.Close(); err != nil {
+		logger.Error("encoder.Close failed",
+			logger.WithError(err),
+			logger.WithField("encoding", encodings[w.encoding].name),
+		)
+	}
+	w.enc.Reset(nil)
+	encodings[w.encoding].pool.Put(w.enc)
+	w.enc = nil
+}
+
+// bodyAllowed reports whether the status allows a body, see RFC 9110 section 6.4.1.
+func bodyAllowed(code int) bool {
+	return code != http.StatusNoContent && code != http.StatusNotModified
+}
@@ -0,0 +1,310 @@
+package servkit
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/suite"
+)
+
+type compressSuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (s *compressSuite) SetupSuite() {
+	dir, err := os.MkdirTemp("", "compress")
+	s.Require().NoError(err)
+	s.dir = dir
+}
+
+func (s *compressSuite) TearDownSuite() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *compressSuite) SetupTest()    {}
+func (s *compressSuite) TearDownTest() {}
+
+func TestCompressSuite(t *testing.T) {
+	suite.Run(t, new(compressSuite))
+}
+
+func decoder(encoding string, r io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "zstd":
+		return zstd.NewReader(r)
+	case "br":
+		return brotli.NewReader(r), nil
+	case "gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		return flate.NewReader(r), nil
+	}
+	return r, nil
+}
+
+func (s *compressSuite) TestNegotiateEncoding() {
+	tests := []struct {
+		Desc   string
+		Accept string
+		Exp    string
+	}{
+		{
+			Desc:   "none",
+			Accept: "",
+			Exp:    "",
+		},
+		{
+			Desc:   "identity only",
+			Accept: "identity",
+			Exp:    "",
+		},
+		{
+			Desc:   "gzip",
+			Accept: "gzip",
+			Exp:    "gzip",
+		},
+		{
+			Desc:   "preference on ties",
+			Accept: "gzip, deflate, br, zstd",
+			Exp:    "zstd",
+		},
+		{
+			Desc:   "quality",
+			Accept: "gzip;q=1.0, br;q=0.5, deflate",
+			Exp:    "gzip",
+		},
+		{
+			Desc:   "rejected",
+			Accept: "zstd;q=0, br;q=0, GZIP",
+			Exp:    "gzip",
+		},
+		{
+			Desc:   "wildcard",
+			Accept: "*;q=0.5, zstd;q=0",
+			Exp:    "br",
+		},
+		{
+			Desc:   "wildcard rejected",
+			Accept: "*;q=0",
+			Exp:    "",
+		},
+	}
+
+	for _, t := range tests {
+		got := ""
+		if i := negotiateEncoding(t.Accept); i >= 0 {
+			got = encodings[i].name
+		}
+		s.Require().Equal(t.Exp, got, t.Desc)
+	}
+}
+
+func (s *compressSuite) TestMiddleware() {
+	large := strings.Repeat(`{"id":"item","name":"example"}`, 100)
+	tests := []struct {
+		Desc        string
+		Accept      string
+		ContentType string
+		Body        string
+		ExpEncoding string
+	}{
+		{
+			Desc:        "zstd",
+			Accept:      "zstd",
+			ContentType: "application/json",
+			Body:        large,
+			ExpEncoding: "zstd",
+		},
+		{
+			Desc:        "br",
+			Accept:      "br",
+			ContentType: "application/json",
+			Body:        large,
+			ExpEncoding: "br",
+		},
+		{
+			Desc:        "gzip",
+			Accept:      "gzip",
+			ContentType: "application/json",
+			Body:        large,
+			ExpEncoding: "gzip",
+		},
+		{
+			Desc:        "deflate",
+			Accept:      "deflate",
+			ContentType: "application/json",
+			Body:        large,
+			ExpEncoding: "deflate",
+		},
+		{
+			Desc:        "sniffed content type",
+			Accept:      "gzip",
+			Body:        large,
+			ExpEncoding: "gzip",
+		},
+		{
+			Desc:        "small body",
+			Accept:      "gzip",
+			ContentType: "application/json",
+			Body:        `{"status":"success"}`,
+		},
+		{
+			Desc:        "compressed content type",
+			Accept:      "gzip",
+			ContentType: "image/png",
+			Body:        large,
+		},
+		{
+			Desc:        "not accepted",
+			ContentType: "application/json",
+			Body:        large,
+		},
+	}
+
+	for _, t := range tests {
+		h := compressMiddleware(defaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if t.ContentType != "" {
+				w.Header().Set("Content-Type", t.ContentType)
+			}
+			w.WriteHeader(http.StatusCreated)
+			// write in pieces to buffer across writes
+			for i := 0; i < len(t.Body); i += 100 {
+				_, err := io.WriteString(w, t.Body[i:min(i+100, len(t.Body))])
+				s.Require().NoError(err, t.Desc)
+			}
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/items", nil)
+		if t.Accept != "" {
+			r.Header.Set("Accept-Encoding", t.Accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		s.Require().Equal(http.StatusCreated, w.Code, t.Desc)
+		s.Require().Equal("Accept-Encoding", w.Header().Get("Vary"), t.Desc)
+		s.Require().Equal(t.ExpEncoding, w.Header().Get("Content-Encoding"), t.Desc)
+		s.Require().NotEmpty(w.Header().Get("Content-Type"), t.Desc)
+		if t.ExpEncoding != "" {
+			s.Require().Less(w.Body.Len(), len(t.Body), t.Desc)
+		}
+
+		dec, err := decoder(t.ExpEncoding, w.Body)
+		s.Require().NoError(err, t.Desc)
+		b, err := io.ReadAll(dec)
+		s.Require().NoError(err, t.Desc)
+		s.Require().Equal(t.Body, string(b), t.Desc)
+	}
+}
+
+func (s *compressSuite) TestNoBody() {
+	h := compressMiddleware(defaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	r := httptest.NewRequest(http.MethodDelete, "/v1/items/1", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	s.Require().Equal(http.StatusNoContent, w.Code)
+	s.Require().Empty(w.Header().Get("Content-Encoding"))
+	s.Require().Zero(w.Body.Len())
+}
+
+func (s *compressSuite) TestStreaming() {
+	chunks := []string{`{"result":{"id":"1"}}` + "\n", `{"result":{"id":"2"}}` + "\n"}
+	flushed := make(chan struct{})
+	next := make(chan struct{})
+
+	h := compressMiddleware(defaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		for i, c := range chunks {
+			_, err := io.WriteString(w, c)
+			s.Require().NoError(err)
+			s.Require().NoError(http.NewResponseController(w).Flush())
+			if i == 0 {
+				close(flushed)
+				<-next
+			}
+		}
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/items:stream", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(w, r)
+	}()
+
+	// the first message is decodable before the stream ends
+	<-flushed
+	s.Require().True(w.Flushed)
+	s.Require().Equal("gzip", w.Header().Get("Content-Encoding"))
+	dec, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+	s.Require().NoError(err)
+	first := make([]byte, len(chunks[0]))
+	_, err = io.ReadFull(dec, first)
+	s.Require().NoError(err)
+	s.Require().Equal(chunks[0], string(first))
+
+	close(next)
+	<-done
+	dec, err = gzip.NewReader(w.Body)
+	s.Require().NoError(err)
+	b, err := io.ReadAll(dec)
+	s.Require().NoError(err)
+	s.Require().Equal(strings.Join(chunks, ""), string(b))
+}
+
+func (s *compressSuite) TestGateway() {
+	tests := []struct {
+		Desc        string
+		Options     []ServOptions
+		ExpEncoding string
+	}{
+		{
+			Desc:        "default",
+			ExpEncoding: "br",
+		},
+		{
+			Desc:    "disabled",
+			Options: []ServOptions{WithCompressMinSize(-1)},
+		},
+	}
+
+	for _, t := range tests {
+		cli, stop := serveExample(&s.Suite, s.dir, t.Options...)
+
+		// promhttp compresses with gzip and zstd by itself, which is kept
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/metrics", nil)
+		s.Require().NoError(err, t.Desc)
+		req.Header.Set("Accept-Encoding", "br")
+		resp, err := cli.Do(req)
+		s.Require().NoError(err, t.Desc)
+
+		s.Require().Equal(http.StatusOK, resp.StatusCode, t.Desc)
+		s.Require().Equal(t.ExpEncoding, resp.Header.Get("Content-Encoding"), t.Desc)
+		dec, err := decoder(t.ExpEncoding, resp.Body)
+		s.Require().NoError(err, t.Desc)
+		b, err := io.ReadAll(dec)
+		s.Require().NoError(err, t.Desc)
+		s.Require().Contains(string(b), "# TYPE", t.Desc)
+		resp.Body.Close()
+
+		stop()
+	}
+}
@@ -0,0 +1,110 @@
+package servkit
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	deadlinepb "demo/proto/deadline"
+)
+
+// deadline bounds how long a method can run, zero means unbounded.
+type deadline struct {
+	def, max time.Duration
+}
+
+// deadliner applies deadlines declared by WithDeadline(), or by the `(deadline.deadline)` method option.
+// Deadlines of WithDeadline() with methods take precedence over the method option,
+// which takes precedence over the ones of WithDeadline() without methods.
+type deadliner struct {
+	methods  map[string]deadline
+	fallback deadline
+
+	cache sync.Map // full method -> deadline
+}
+
+func newDeadliner(o *servOptions) *deadliner {
+	return &deadliner{methods: o.deadlines, fallback: o.defaultDeadline}
+}
+
+func (d *deadliner) unary(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, cancel := d.apply(ctx, info.FullMethod)
+	defer cancel()
+
+	return handler(ctx, req)
+}
+
+func (d *deadliner) stream(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	ctx, cancel := d.apply(ss.Context(), info.FullMethod)
+	defer cancel()
+
+	wss := newStreamContextWrapper(ss)
+	wss.SetContext(ctx)
+	return handler(srv, wss)
+}
+
+// apply sets the default deadline if the client doesn't set one, or caps the one longer than the maximum.
+func (d *deadliner) apply(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	dl := d.lookup(method)
+
+	timeout := dl.def
+	if t, ok := ctx.Deadline(); ok {
+		if dl.max <= 0 || time.Until(t) <= dl.max {
+			return ctx, func() {}
+		}
+		timeout = dl.max
+	} else if timeout <= 0 {
+		timeout = dl.max
+	}
+
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (d *deadliner) lookup(method string) deadline {
+	if dl, ok := d.methods[method]; ok {
+		return dl
+	}
+	if dl, ok := d.cache.Load(method); ok {
+		return dl.(deadline)
+	}
+
+	dl, ok := methodDeadline(method)
+	if !ok {
+		dl = d.fallback
+	}
+	d.cache.Store(method, dl)
+
+	return dl
+}
+
+// methodDeadline reads the `(deadline.deadline)` option of the full method, ex: "/example.Example/Login".
+func methodDeadline(method string) (deadline, bool) {
+	name := protoreflect.FullName(strings.Replace(strings.TrimPrefix(method, "/"), "/", ".", 1))
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return deadline{}, false
+	}
+	md, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok || md.Options() == nil || !proto.HasExtension(md.Options(), deadlinepb.E_Deadline) {
+		return deadline{}, false
+	}
+
+	opt := proto.GetExtension(md.Options(), deadlinepb.E_Deadline).(*deadlinepb.Deadline)
+	return deadline{
+		def: opt.GetDefaultTimeout().AsDuration(),
+		max: opt.GetMaxTimeout().AsDuration(),
+	}, true
+}
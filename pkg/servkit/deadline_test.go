@@ -0,0 +1,112 @@
+package servkit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+
+	_ "demo/proto/example"
+)
+
+type deadlineSuite struct {
+	suite.Suite
+}
+
+func (s *deadlineSuite) SetupSuite()    {}
+func (s *deadlineSuite) TearDownSuite() {}
+func (s *deadlineSuite) SetupTest()     {}
+func (s *deadlineSuite) TearDownTest()  {}
+
+func TestDeadlineSuite(t *testing.T) {
+	suite.Run(t, new(deadlineSuite))
+}
+
+func (s *deadlineSuite) TestDeadline() {
+	const (
+		login     = "/example.Example/Login"
+		listItems = "/example.Example/ListItems"
+	)
+
+	tests := []struct {
+		Desc       string
+		Options    []ServOptions
+		Method     string
+		Timeout    time.Duration
+		ExpTimeout time.Duration
+	}{
+		{
+			Desc:       "default of the method option",
+			Method:     login,
+			ExpTimeout: 5 * time.Second,
+		},
+		{
+			Desc:       "capped by the method option",
+			Method:     login,
+			Timeout:    time.Minute,
+			ExpTimeout: 10 * time.Second,
+		},
+		{
+			Desc:       "shorter than the max",
+			Method:     login,
+			Timeout:    time.Second,
+			ExpTimeout: time.Second,
+		},
+		{
+			Desc:       "options with methods override the method option",
+			Options:    []ServOptions{WithDeadline(2*time.Second, 3*time.Second, login)},
+			Method:     login,
+			Timeout:    time.Minute,
+			ExpTimeout: 3 * time.Second,
+		},
+		{
+			Desc:       "method option overrides options without methods",
+			Options:    []ServOptions{WithDeadline(time.Second, time.Second)},
+			Method:     login,
+			ExpTimeout: 5 * time.Second,
+		},
+		{
+			Desc:       "options without methods",
+			Options:    []ServOptions{WithDeadline(time.Second, 0)},
+			Method:     listItems,
+			ExpTimeout: time.Second,
+		},
+		{
+			Desc:       "max only",
+			Options:    []ServOptions{WithDeadline(0, 2*time.Second)},
+			Method:     listItems,
+			ExpTimeout: 2 * time.Second,
+		},
+		{
+			Desc:   "unbounded",
+			Method: listItems,
+		},
+	}
+
+	for _, t := range tests {
+		d := newDeadliner(applyServOptions(t.Options...))
+
+		ctx := context.Background()
+		if t.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+			defer cancel()
+		}
+
+		_, err := d.unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: t.Method},
+			func(ctx context.Context, _ interface{}) (interface{}, error) {
+				dl, ok := ctx.Deadline()
+				if t.ExpTimeout == 0 {
+					s.Require().False(ok, t.Desc)
+					return nil, nil
+				}
+				s.Require().True(ok, t.Desc)
+				s.Require().InDelta(t.ExpTimeout, time.Until(dl), float64(100*time.Millisecond), t.Desc)
+				return nil, nil
+			},
+		)
+		s.Require().NoError(err, t.Desc)
+	}
+}
@@ -1,3 +1,11 @@
 // Package servkit is responsible for launching multiple servers,
-// and stop them gracefully based on the shutdown-level.
+// and stop them gracefully based on the shutdown-level. In-flight requests are drained within a drain window,
+// after which the servers stop hard.
+// Servers listen on tcp addresses or unix domain sockets, optionally over TLS or mTLS
+// with certificates reloaded on SIGHUP.
+// The gRPC server can register the standard health service tied to bootkit probes, and server reflection per namespace.
+// The gateway can call the gRPC server in the same process in memory with WithInProcess().
+// Gateway errors are rendered in the JSON envelope of demo/proto/envelope with RegisterHTTPErrorHandler().
+// Gateway responses are compressed as negotiated by Accept-Encoding, see WithCompressMinSize().
+// RunMuxServer() serves gRPC and the gateway on a single port instead of RunGrpcServer() and RunGrpcGateway().
 package servkit
@@ -0,0 +1,197 @@
+package servkit
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"demo/pkg/logger"
+)
+
+// defaultDrainTimeout leaves a few seconds of the shutdown budget of bootkit for the hard stop.
+const defaultDrainTimeout = 20 * time.Second
+
+// drainToken is the metadata the gateway attaches when dialing the gRPC server,
+// so requests the gateway accepted before draining can still reach the server in the same process.
+const drainToken = SpecifiedHeaderPrefix + "drain-token"
+
+// processDrainToken is shared by drainers of the same process, which trust requests of each other,
+// ex: RunGrpcGateway() calling RunGrpcServer() in the same process. Servers in other processes don't.
+var processDrainToken = uuid.NewString()
+
+var errDraining = status.Error(codes.Unavailable, "server is shutting down")
+
+// drainer counts in-flight requests, and rejects new ones once draining starts.
+type drainer struct {
+	token string
+
+	mux      sync.Mutex
+	draining bool
+	inFlight int
+	idle     chan struct{} // closed once no request is in flight while draining
+}
+
+func newDrainer() *drainer {
+	return &drainer{
+		token: processDrainToken,
+		idle:  make(chan struct{}),
+	}
+}
+
+// acquire counts the request in flight, it returns false if the server is draining.
+func (d *drainer) acquire() bool {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.draining {
+		return false
+	}
+	d.inFlight++
+
+	return true
+}
+
+func (d *drainer) release() {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.inFlight--
+	if d.draining && d.inFlight == 0 {
+		close(d.idle)
+	}
+}
+
+// start rejects new requests, and returns the channel closed once in-flight requests finish.
+func (d *drainer) start() <-chan struct{} {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if !d.draining {
+		d.draining = true
+		if d.inFlight == 0 {
+			close(d.idle)
+		}
+	}
+
+	return d.idle
+}
+
+func (d *drainer) count() int {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	return d.inFlight
+}
+
+// stop drains the server with graceful, and falls back to force once timeout passes, zero means unbounded.
+// Requests still in flight are cut off by force.
+func (d *drainer) stop(name string, timeout time.Duration, graceful func() error, force func()) error {
+	idle := d.start()
+
+	done := make(chan error, 1)
+	go func() {
+		err := graceful()
+		<-idle
+		done <- err
+	}()
+
+	if timeout <= 0 {
+		return <-done
+	}
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-t.C:
+	}
+
+	logger.Warn("drain timed out, cutting off in-flight requests", logger.WithFields(logger.Fields{
+		"server":   name,
+		"timeout":  timeout.String(),
+		"inFlight": d.count(),
+	}))
+	force()
+
+	return nil
+}
+
+// trusted reports whether the request is sent by the gateway in the same process, which has been counted.
+func (d *drainer) trusted(ctx context.Context) bool {
+	md, ok := GetMetadata(ctx)
+	return ok && md.getSpecKey(drainToken) == d.token
+}
+
+func (d *drainer) unary(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if d.trusted(ctx) {
+		return handler(ctx, req)
+	}
+	if !d.acquire() {
+		return nil, errDraining
+	}
+	defer d.release()
+
+	return handler(ctx, req)
+}
+
+func (d *drainer) stream(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	if d.trusted(ss.Context()) {
+		return handler(srv, ss)
+	}
+	if !d.acquire() {
+		return errDraining
+	}
+	defer d.release()
+
+	return handler(srv, ss)
+}
+
+// middleware rejects new requests to the gateway with 503 while draining, and closes the connection
+// so clients reconnect to other pods. The error is rendered by the error handler of mux like other gateway errors,
+// ex: the envelope of RegisterHTTPErrorHandler().
+func (d *drainer) middleware(mux *gwruntime.ServeMux) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !d.acquire() {
+				w.Header().Set("Connection", "close")
+				ctx := gwruntime.NewServerMetadataContext(r.Context(), gwruntime.ServerMetadata{})
+				_, outbound := gwruntime.MarshalerForRequest(mux, r)
+				gwruntime.HTTPError(ctx, mux, outbound, w, r, errDraining)
+				return
+			}
+			defer d.release()
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// injectTokenUnary marks requests dialed by the gateway to the server.
+func (d *drainer) injectTokenUnary(
+	ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+) error {
+	return invoker(metadata.AppendToOutgoingContext(ctx, drainToken, d.token), method, req, reply, cc, opts...)
+}
+
+// injectTokenStream marks streams dialed by the gateway to the server.
+func (d *drainer) injectTokenStream(
+	ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+	streamer grpc.Streamer, opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	return streamer(metadata.AppendToOutgoingContext(ctx, drainToken, d.token), desc, cc, method, opts...)
+}
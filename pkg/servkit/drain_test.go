@@ -0,0 +1,234 @@
+package servkit
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"demo/pkg/bootkit"
+	examplePb "demo/proto/example"
+)
+
+type drainSuite struct {
+	suite.Suite
+}
+
+func (s *drainSuite) SetupSuite()    {}
+func (s *drainSuite) TearDownSuite() {}
+func (s *drainSuite) SetupTest()     {}
+func (s *drainSuite) TearDownTest()  {}
+
+func TestDrainSuite(t *testing.T) {
+	suite.Run(t, new(drainSuite))
+}
+
+func (s *drainSuite) TestStop() {
+	tests := []struct {
+		Desc     string
+		Timeout  time.Duration
+		Hold     time.Duration
+		ExpForce bool
+	}{
+		{
+			Desc:    "drained in time",
+			Timeout: time.Second,
+			Hold:    10 * time.Millisecond,
+		},
+		{
+			Desc:     "cut off",
+			Timeout:  10 * time.Millisecond,
+			Hold:     time.Second,
+			ExpForce: true,
+		},
+		{
+			Desc: "unbounded",
+			Hold: 10 * time.Millisecond,
+		},
+	}
+
+	for _, t := range tests {
+		d := newDrainer()
+		s.Require().True(d.acquire(), t.Desc)
+		released := make(chan struct{})
+		time.AfterFunc(t.Hold, func() {
+			d.release()
+			close(released)
+		})
+
+		var forced atomic.Bool
+		err := d.stop("test", t.Timeout, func() error { return nil }, func() { forced.Store(true) })
+		s.Require().NoError(err, t.Desc)
+		s.Require().Equal(t.ExpForce, forced.Load(), t.Desc)
+		s.Require().False(d.acquire(), t.Desc)
+		<-released
+	}
+}
+
+func (s *drainSuite) TestReject() {
+	d := newDrainer()
+	d.start()
+
+	handler := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	info := &grpc.UnaryServerInfo{FullMethod: "/example.Example/Login"}
+
+	_, err := d.unary(context.Background(), nil, info, handler)
+	s.Require().Equal(codes.Unavailable, status.Code(err))
+
+	// requests of the gateway accepted before draining are served
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(drainToken, d.token))
+	resp, err := d.unary(ctx, nil, info, handler)
+	s.Require().NoError(err)
+	s.Require().Equal("ok", resp)
+
+	// the gateway renders the rejection in the envelope like other errors
+	mux := gwruntime.NewServeMux(RegisterHTTPErrorHandler())
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/items", nil)
+	r.Header.Set(RequestID, "req-1")
+	d.middleware(mux)(http.NotFoundHandler()).ServeHTTP(w, r)
+	s.Require().Equal(http.StatusServiceUnavailable, w.Code)
+	s.Require().Equal("close", w.Header().Get("Connection"))
+	s.Require().Equal("application/json", w.Header().Get("Content-Type"))
+
+	var body errorEnvelope
+	s.Require().NoError(json.NewDecoder(w.Body).Decode(&body))
+	s.Require().Equal(errorEnvelope{
+		Status:    defaultErrorStatus,
+		Code:      int(codes.Unavailable),
+		Message:   "server is shutting down",
+		RequestID: "req-1",
+	}, body)
+}
+
+func (s *drainSuite) TestHardStop() {
+	app := bootkit.New()
+	addr := "unix://" + filepath.Join(s.T().TempDir(), "grpc.sock")
+	app.RegisterServer("grpc-server", func(shutdown bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
+		return RunGrpcServer(context.Background(), addr, shutdown,
+			func(serv *grpc.Server) {
+				examplePb.RegisterExampleServer(serv, examplePb.UnimplementedExampleServer{})
+			},
+			WithBootApp(app),
+			WithReady(ready),
+			WithHealth(10*time.Millisecond),
+			WithDrainTimeout(100*time.Millisecond),
+		)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(app.Run(ctx))
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	// the watch stream is held open until the server stops
+	stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	s.Require().NoError(err)
+	_, err = stream.Recv()
+	s.Require().NoError(err)
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		app.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		s.FailNow("shutdown is blocked by the stream")
+	}
+
+	for {
+		if _, err := stream.Recv(); err != nil {
+			s.Require().Equal(codes.Unavailable, status.Code(err))
+			break
+		}
+	}
+}
+
+func (s *drainSuite) TestSplitDrain() {
+	dir := s.T().TempDir()
+	grpcAddr := "unix://" + filepath.Join(dir, "grpc.sock")
+	gwSock := filepath.Join(dir, "gw.sock")
+
+	// hold requests accepted by the gateway before they reach the gRPC server
+	entered, release := make(chan struct{}), make(chan struct{})
+	hold := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			<-release
+			h.ServeHTTP(w, r)
+		})
+	}
+
+	app := bootkit.New()
+	app.RegisterServer("grpc-server", func(shutdown bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
+		return RunGrpcServer(context.Background(), grpcAddr, shutdown,
+			func(serv *grpc.Server) {
+				examplePb.RegisterExampleServer(serv, exampleServer{})
+			},
+			WithBootApp(app),
+			WithReady(ready),
+		)
+	})
+	app.RegisterServer("grpc-gateway", func(shutdown bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
+		return RunGrpcGateway(context.Background(), "unix://"+gwSock, grpcAddr, shutdown,
+			func(ctx context.Context, mux *gwruntime.ServeMux, conn *grpc.ClientConn) error {
+				return examplePb.RegisterExampleHandler(ctx, mux, conn)
+			},
+			WithBootApp(app),
+			WithReady(ready),
+			WithMiddlewares(hold),
+		)
+	}, bootkit.WithDependsOn("grpc-server"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(app.Run(ctx))
+
+	cli := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", gwSock)
+			},
+		},
+	}
+	code := make(chan int, 1)
+	go func() {
+		resp, err := cli.Post("http://localhost/v1/login", "application/json",
+			strings.NewReader(`{"Username":"user","Password":"pass"}`))
+		if err != nil {
+			code <- 0
+			return
+		}
+		resp.Body.Close()
+		code <- resp.StatusCode
+	}()
+
+	<-entered
+	cancel()
+	// the gRPC server keeps serving until the gateway is drained
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+
+	s.Require().Equal(http.StatusOK, <-code)
+	app.Wait()
+}
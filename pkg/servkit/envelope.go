@@ -0,0 +1,100 @@
+package servkit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"demo/pkg/telemetrykit"
+	envelopepb "demo/proto/envelope"
+)
+
+const defaultErrorStatus = "error"
+
+// RegisterHTTPErrorHandler registers customized http handler, which renders errors in the envelope.Error, ex:
+// {"status":"error","code":10002,"message":"invalid params","fieldViolations":[...],"traceId":"...","requestId":"..."}
+// The HTTP status is the one injected by InjectHTTPCode(), or mapped from the code, see WithHTTPStatus().
+func RegisterHTTPErrorHandler(options ...ErrorOptions) gwruntime.ServeMuxOption {
+	o := applyErrorOptions(options...)
+	return gwruntime.WithErrorHandler(func(
+		ctx context.Context, mux *gwruntime.ServeMux, m gwruntime.Marshaler,
+		w http.ResponseWriter, r *http.Request, err error,
+	) {
+		httpStatus := 0
+		// routing errors of the gateway have the HTTP status already, ex: 404 and 405
+		var herr *gwruntime.HTTPStatusError
+		if errors.As(err, &herr) {
+			httpStatus, err = herr.HTTPStatus, herr.Err
+		}
+
+		s := status.Convert(err)
+		if httpStatus == 0 {
+			httpStatus = o.httpStatus(s.Code())
+		}
+		// override default status code based on native mechanism
+		if code, _ := extractStatusCode(ctx); code != 0 {
+			httpStatus = code
+		}
+
+		em := &envelopeMarshaler{Marshaler: m, envelope: o.envelope(ctx, r, s)}
+		gwruntime.DefaultHTTPErrorHandler(ctx, mux, em, w, r, &gwruntime.HTTPStatusError{HTTPStatus: httpStatus, Err: err})
+	})
+}
+
+// httpStatus maps the gRPC or business code to the HTTP status, unknown business codes are 500.
+func (o *errorOptions) httpStatus(code codes.Code) int {
+	if s, ok := o.httpStatuses[code]; ok {
+		return s
+	}
+
+	// HTTPStatusFromCode() falls back to 500 as well
+	return gwruntime.HTTPStatusFromCode(code)
+}
+
+func (o *errorOptions) envelope(ctx context.Context, r *http.Request, s *status.Status) *envelopepb.Error {
+	traceID, _ := telemetrykit.TraceIDs(ctx)
+	e := &envelopepb.Error{
+		Status:    o.status,
+		Code:      int32(s.Code()),
+		Message:   s.Message(),
+		TraceId:   traceID,
+		RequestId: r.Header.Get(RequestID),
+	}
+
+	for _, d := range s.Details() {
+		br, ok := d.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, v := range br.GetFieldViolations() {
+			e.FieldViolations = append(e.FieldViolations, &envelopepb.FieldViolation{
+				Field:       v.GetField(),
+				Description: v.GetDescription(),
+			})
+		}
+	}
+
+	return e
+}
+
+// envelopeMarshaler renders the envelope instead of the google.rpc.Status given by DefaultHTTPErrorHandler(),
+// which still forwards the headers and trailers of the failed request.
+type envelopeMarshaler struct {
+	gwruntime.Marshaler
+
+	envelope *envelopepb.Error
+}
+
+func (m *envelopeMarshaler) Marshal(interface{}) ([]byte, error) {
+	return protojson.Marshal(m.envelope)
+}
+
+func (m *envelopeMarshaler) ContentType(interface{}) string {
+	return "application/json"
+}
@@ -0,0 +1,204 @@
+package servkit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"demo/pkg/errorKit"
+)
+
+type envelopeSuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (s *envelopeSuite) SetupSuite() {
+	dir, err := os.MkdirTemp("", "envelope")
+	s.Require().NoError(err)
+	s.dir = dir
+}
+
+func (s *envelopeSuite) TearDownSuite() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *envelopeSuite) SetupTest()    {}
+func (s *envelopeSuite) TearDownTest() {}
+
+func TestEnvelopeSuite(t *testing.T) {
+	suite.Run(t, new(envelopeSuite))
+}
+
+type errorEnvelope struct {
+	Status          string `json:"status"`
+	Code            int    `json:"code"`
+	Message         string `json:"message"`
+	FieldViolations []struct {
+		Field       string `json:"field"`
+		Description string `json:"description"`
+	} `json:"fieldViolations"`
+	TraceID   string `json:"traceId"`
+	RequestID string `json:"requestId"`
+}
+
+func (s *envelopeSuite) TestHTTPStatus() {
+	tests := []struct {
+		Desc       string
+		Options    []ErrorOptions
+		Err        error
+		ExpStatus  int
+		ExpCode    int
+		ExpMessage string
+	}{
+		{
+			Desc:       "grpc code",
+			Err:        status.Error(codes.NotFound, "not found"),
+			ExpStatus:  http.StatusNotFound,
+			ExpCode:    int(codes.NotFound),
+			ExpMessage: "not found",
+		},
+		{
+			Desc:       "internal error of errorKit",
+			Err:        errorKit.ErrRecordNotFound,
+			ExpStatus:  http.StatusInternalServerError,
+			ExpCode:    errorKit.StatusBoInternalError,
+			ExpMessage: "record not found",
+		},
+		{
+			Desc:       "invalid params of errorKit",
+			Err:        errorKit.ErrInvalidParams,
+			ExpStatus:  http.StatusBadRequest,
+			ExpCode:    errorKit.StatusBoInvalidParams,
+			ExpMessage: "invalid params",
+		},
+		{
+			Desc:       "ip not allowed of errorKit",
+			Err:        status.Error(errorKit.StatusBoIpNotAllowed, "ip not allowed"),
+			ExpStatus:  http.StatusForbidden,
+			ExpCode:    errorKit.StatusBoIpNotAllowed,
+			ExpMessage: "ip not allowed",
+		},
+		{
+			Desc:       "unknown business code",
+			Err:        status.Error(12345, "oops"),
+			ExpStatus:  http.StatusInternalServerError,
+			ExpCode:    12345,
+			ExpMessage: "oops",
+		},
+		{
+			Desc:       "configured business code",
+			Options:    []ErrorOptions{WithHTTPStatus(errorKit.StatusBoIpNotAllowed, http.StatusUnauthorized)},
+			Err:        status.Error(errorKit.StatusBoIpNotAllowed, "ip not allowed"),
+			ExpStatus:  http.StatusUnauthorized,
+			ExpCode:    errorKit.StatusBoIpNotAllowed,
+			ExpMessage: "ip not allowed",
+		},
+		{
+			Desc: "routing error of gateway",
+			Err: &gwruntime.HTTPStatusError{
+				HTTPStatus: http.StatusMethodNotAllowed,
+				Err:        status.Error(codes.Unimplemented, "Method Not Allowed"),
+			},
+			ExpStatus:  http.StatusMethodNotAllowed,
+			ExpCode:    int(codes.Unimplemented),
+			ExpMessage: "Method Not Allowed",
+		},
+	}
+
+	ctx := gwruntime.NewServerMetadataContext(context.Background(), gwruntime.ServerMetadata{})
+	for _, t := range tests {
+		mux := gwruntime.NewServeMux(RegisterHTTPErrorHandler(t.Options...))
+		r := httptest.NewRequest(http.MethodGet, "/v1/example", nil)
+		r.Header.Set(RequestID, "req-1")
+		w := httptest.NewRecorder()
+
+		gwruntime.HTTPError(ctx, mux, &gwruntime.JSONPb{}, w, r, t.Err)
+		s.Require().Equal(t.ExpStatus, w.Code, t.Desc)
+		s.Require().Equal("application/json", w.Header().Get("Content-Type"), t.Desc)
+
+		var body errorEnvelope
+		s.Require().NoError(json.NewDecoder(w.Body).Decode(&body), t.Desc)
+		s.Require().Equal(defaultErrorStatus, body.Status, t.Desc)
+		s.Require().Equal(t.ExpCode, body.Code, t.Desc)
+		s.Require().Equal(t.ExpMessage, body.Message, t.Desc)
+		s.Require().Equal("req-1", body.RequestID, t.Desc)
+	}
+}
+
+func (s *envelopeSuite) TestErrorStatus() {
+	ctx := gwruntime.NewServerMetadataContext(context.Background(), gwruntime.ServerMetadata{})
+	mux := gwruntime.NewServeMux(RegisterHTTPErrorHandler(WithErrorStatus("failure")))
+	w := httptest.NewRecorder()
+
+	gwruntime.HTTPError(ctx, mux, &gwruntime.JSONPb{}, w,
+		httptest.NewRequest(http.MethodGet, "/", nil), status.Error(codes.Internal, "internal"))
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+
+	var body errorEnvelope
+	s.Require().NoError(json.NewDecoder(w.Body).Decode(&body))
+	s.Require().Equal("failure", body.Status)
+}
+
+func (s *envelopeSuite) TestGatewayBadRequest() {
+	cli, stop := serveExample(&s.Suite, s.dir, WithGWServMuxOptions(RegisterHTTPErrorHandler()))
+	defer stop()
+
+	tests := []struct {
+		Desc         string
+		RequestID    string
+		ExpRequestID string
+	}{
+		{
+			Desc:         "given request id",
+			RequestID:    "req-1",
+			ExpRequestID: "req-1",
+		},
+		{
+			Desc: "generated request id",
+		},
+		{
+			Desc:      "too long request id",
+			RequestID: strings.Repeat("x", maxRequestIDLen+1),
+		},
+	}
+
+	for _, t := range tests {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost/v1/login", strings.NewReader(`{"Username":"user"}`))
+		s.Require().NoError(err, t.Desc)
+		req.Header.Set("Content-Type", "application/json")
+		if t.RequestID != "" {
+			req.Header.Set(RequestID, t.RequestID)
+		}
+
+		resp, err := cli.Do(req)
+		s.Require().NoError(err, t.Desc)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode, t.Desc)
+
+		var body errorEnvelope
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&body), t.Desc)
+		resp.Body.Close()
+
+		s.Require().Equal(defaultErrorStatus, body.Status, t.Desc)
+		s.Require().Equal(int(codes.InvalidArgument), body.Code, t.Desc)
+		s.Require().Len(body.FieldViolations, 1, t.Desc)
+		s.Require().Equal("Password", body.FieldViolations[0].Field, t.Desc)
+		s.Require().NotEmpty(body.TraceID, t.Desc)
+		s.Require().NotEmpty(body.RequestID, t.Desc)
+		s.Require().Equal(resp.Header.Get(RequestID), body.RequestID, t.Desc)
+		if t.ExpRequestID != "" {
+			s.Require().Equal(t.ExpRequestID, body.RequestID, t.Desc)
+		}
+		s.Require().LessOrEqual(len(body.RequestID), maxRequestIDLen, t.Desc)
+	}
+}
@@ -2,17 +2,19 @@ package servkit
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/tomasen/realip"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/encoding/protojson"
@@ -23,6 +25,7 @@ import (
 	"demo/pkg/envkit"
 	"demo/pkg/httpkit"
 	"demo/pkg/logger"
+	"demo/pkg/metrickit"
 )
 
 var (
@@ -30,6 +33,11 @@ var (
 	ErrInvalidStatusCode = errors.New("invalid status code")
 )
 
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultIdleTimeout       = 2 * time.Minute
+)
+
 type RegisterHandlerFunc func(ctx context.Context, mux *gwruntime.ServeMux, conn *grpc.ClientConn) error
 
 func RunGrpcGateway(
@@ -41,27 +49,172 @@ func RunGrpcGateway(
 ) error {
 	o := applyServOptions(options...)
 
-	o.grpcDialOpts = append(o.grpcDialOpts, grpc.WithBlock(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	creds, err := dialCredentials(ctx, o)
+	if err != nil {
+		return err
+	}
+
+	dr := newDrainer()
+	conn, err := dialGrpcServer(ctx, grpcAddr, creds, o, dr)
+	if err != nil {
+		return err
+	}
+
+	handler, err := newGatewayHandler(ctx, o, dr, conn, registerHandlers)
+	if err != nil {
+		return err
+	}
+
+	lis, err := listen(gwAddr)
+	if err != nil {
+		logger.Ctx(ctx).Error("listen failed",
+			logger.WithError(err),
+			logger.WithField("addr", gwAddr),
+		)
+		return err
+	}
+
+	s := &http.Server{
+		Addr:              gwAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: o.readHeaderTimeout,
+		WriteTimeout:      o.writeTimeout,
+		IdleTimeout:       o.idleTimeout,
+	}
+
+	shutdown(func() error {
+		// close gRPC gateway, requests still in flight are cut off once the drain timeout passes
+		logger.Info("Shutting down gRPC gateway ...")
+		err := dr.stop("grpc-gateway", o.drainTimeout, func() error {
+			return s.Shutdown(context.Background())
+		}, func() { _ = s.Close() })
+		if err != nil {
+			return err
+		}
+
+		// close connection
+		return conn.Close()
+	}, bootkit.WithShutdownName("grpc-gateway"))
+
+	logger.Ctx(ctx).Info(fmt.Sprintf("Starting gRPC gateway listening at %s", gwAddr))
+
+	o.ready()
+	if err := s.Serve(lis); err != http.ErrServerClosed {
+		logger.Ctx(ctx).Error("Failed to listen and serve", logger.WithError(err))
+		return err
+	}
+
+	return nil
+}
+
+// dialGrpcServer dials the gRPC server at grpcAddr, and blocks until it's connected.
+// The server in the same process is connected in memory with WithInProcess(), which doesn't block
+// since the server is serving already once the gateway depends on it in bootkit.
+func dialGrpcServer(
+	ctx context.Context, grpcAddr string, creds credentials.TransportCredentials, o *servOptions, dr *drainer,
+) (*grpc.ClientConn, error) {
+	// requests of the gateway are marked, so a gRPC server in the same process serves them while draining.
+	o.grpcDialOpts = append(o.grpcDialOpts, grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(injectTraceUnary, dr.injectTokenUnary),
+		grpc.WithChainStreamInterceptor(injectTraceStream, dr.injectTokenStream),
+	)
+
+	if o.inProcess != nil {
+		// the connection is kept instead of going idle, otherwise the gateway probe fails while there is no traffic.
+		o.grpcDialOpts = append(o.grpcDialOpts, grpc.WithContextDialer(o.inProcess.dial), grpc.WithIdleTimeout(0))
+		conn, err := grpc.NewClient(inProcessTarget, o.grpcDialOpts...)
+		if err != nil {
+			logger.Ctx(ctx).Error("grpc.NewClient failed", logger.WithError(err))
+			return nil, err
+		}
+		conn.Connect()
+
+		return conn, nil
+	}
+
+	o.grpcDialOpts = append(o.grpcDialOpts, grpc.WithBlock())
 	conn, err := grpc.DialContext(ctx, grpcAddr, o.grpcDialOpts...)
 	if err != nil {
 		logger.Ctx(ctx).Error("grpc.DialContext failed",
 			logger.WithError(err),
 			logger.WithField("grpcAddr", grpcAddr),
 		)
-		return err
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// dialCredentials returns the credentials dialing the gRPC server,
+// the client certificate and CA are reloaded on SIGHUP. It's insecure without WithDialTLS().
+func dialCredentials(ctx context.Context, o *servOptions) (credentials.TransportCredentials, error) {
+	if o.dialTLS == nil {
+		return insecure.NewCredentials(), nil
+	}
+
+	l, err := newCertLoader(o.dialTLS.certFile, o.dialTLS.keyFile, o.dialTLS.caFile)
+	if err != nil {
+		logger.Ctx(ctx).Error("newCertLoader failed", logger.WithError(err))
+		return nil, err
 	}
+	o.app.AddReloadHandler("grpc-gateway-tls", l.Reload)
+
+	return credentials.NewTLS(l.clientConfig(o.dialServerName)), nil
+}
 
+// newGatewayHandler returns the handler serving the gateway and the health endpoints, wrapped by middlewares.
+func newGatewayHandler(
+	ctx context.Context,
+	o *servOptions,
+	dr *drainer,
+	conn *grpc.ClientConn,
+	registerHandlers RegisterHandlerFunc,
+) (http.Handler, error) {
 	httpMux := http.NewServeMux()
-	httpMux.HandleFunc("/health", healthGRPCServer(conn))
+	handle := func(pattern string, h http.HandlerFunc) {
+		httpMux.HandleFunc(pattern, withRoute(pattern, h))
+	}
+	handle("/health", healthGRPCServer(conn))
 	// k8s API health endpoints
 	// ref: https://kubernetes.io/docs/reference/using-api/health-checks/
-	httpMux.HandleFunc("/healthz", healthzGRPCServer(conn))
+	handle("/healthz", healthzGRPCServer(conn))
+	// aggregated probes registered in bootkit
+	handle("/readyz", probeHandler(o.app.CheckReadiness))
+	handle("/livez", probeHandler(o.app.CheckLiveness))
+	// lifecycle events recorded in bootkit, which are internal and not exposed in production by default
+	if enabledIn(o.lifecycleEnvs) {
+		handle("/debug/lifecycle", lifecycleHandler(o.app))
+	}
+	// metrics in Prometheus text format
+	handle("/metrics", metrickit.Handler().ServeHTTP)
+	// OpenAPI specifications and Swagger UI
+	if o.openAPI != nil && enabledIn(o.openAPIEnvs) {
+		docs, err := newOpenAPIDocs(o.openAPI)
+		if err != nil {
+			logger.Ctx(ctx).Error("newOpenAPIDocs failed", logger.WithError(err))
+			return nil, err
+		}
+		handle(openAPIPrefix, docs.specs)
+		handle("/docs", docs.ui)
+		handle(docsAssetsPrefix, docs.assets)
+	}
 
+	o.app.AddProbe("grpc-gateway-conn", func(ctx context.Context) error {
+		if s := conn.GetState(); s != connectivity.Ready {
+			return fmt.Errorf("grpc connection is %s", s)
+		}
+		return nil
+	})
+
+	// forward request ID, idempotency and rate limit headers by default,
+	// which is overridden by RegisterForwardHeaders() and RegisterResponseHeaders() in options
+	o.gwServMuxOpts = append([]gwruntime.ServeMuxOption{RegisterForwardHeaders(nil), RegisterResponseHeaders(nil)},
+		o.gwServMuxOpts...)
 	o.gwServMuxOpts = append(o.gwServMuxOpts, enrichMetaData())
 	gwMux := gwruntime.NewServeMux(o.gwServMuxOpts...)
 	if err := registerHandlers(ctx, gwMux, conn); err != nil {
 		logger.Ctx(ctx).Error("registerHandler failed", logger.WithError(err))
-		return err
+		return nil, err
 	}
 
 	// don't allow CORS in Production
@@ -72,34 +225,23 @@ func RunGrpcGateway(
 	// check if content-encoding is gzip, and decompress it
 	o.middlewares = append(o.middlewares, middleware.GZipDecompressor, middleware.PayloadMiddleware)
 
-	chain := httpkit.NewChain(o.middlewares...)
-
-	httpMux.Handle("/", gwMux)
+	// honor idempotency keys of mutating methods only
+	o.middlewares = append(o.middlewares, idempotencyMiddleware)
 
-	s := &http.Server{
-		Addr:    gwAddr,
-		Handler: chain.Then(httpMux),
+	// identify, observe and trace requests before other middlewares, which may respond early,
+	// and reject new requests while draining
+	observers := []httpkit.Middleware{
+		requestIDMiddleware, metricsMiddleware, tracingMiddleware(o.telemetry), dr.middleware(gwMux),
 	}
-
-	shutdown(func() error {
-		// close gRPC gateway
-		logger.Info("Shutting down gRPC gateway ...")
-		if err := s.Shutdown(context.Background()); err != nil {
-			return err
-		}
-
-		// close connection
-		return conn.Close()
-	})
-
-	logger.Ctx(ctx).Info(fmt.Sprintf("Starting gRPC gateway listening at %s", gwAddr))
-
-	if err := s.ListenAndServe(); err != http.ErrServerClosed {
-		logger.Ctx(ctx).Error("Failed to listen and serve", logger.WithError(err))
-		return err
+	// compress responses of other middlewares as well
+	if o.compressMinSize >= 0 {
+		observers = append(observers, compressMiddleware(o.compressMinSize))
 	}
+	chain := httpkit.NewChain(append(observers, o.middlewares...)...)
 
-	return nil
+	httpMux.Handle("/", gwMux)
+
+	return chain.Then(httpMux), nil
 }
 
 // healthGRPCServer returns a simple health handler which returns {"ok":true}.
@@ -127,19 +269,31 @@ func healthzGRPCServer(conn *grpc.ClientConn) http.HandlerFunc {
 	}
 }
 
-// openAPIServer returns OpenAPI specification files located under "/openapiv2/"
-func openAPIServer(dir string) http.HandlerFunc {
+// probeHandler returns a handler rendering the aggregated report of bootkit probes with per-check detail.
+func probeHandler(check func(context.Context) bootkit.ProbeReport) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if !strings.HasSuffix(r.URL.Path, ".swagger.json") {
-			logger.Error(fmt.Sprintf("Not Found: %s", r.URL.Path))
-			http.NotFound(w, r)
-			return
+		report := check(r.Context())
+
+		code := http.StatusOK
+		if !report.Healthy() {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			logger.Ctx(r.Context()).Error("json.Encode failed", logger.WithError(err))
 		}
+	}
+}
 
-		p := strings.TrimPrefix(r.URL.Path, "/openapiv2/")
-		p = path.Join(dir, p)
-		logger.Info(fmt.Sprintf("Serving %s", r.URL.Path), logger.WithField("path", p))
-		http.ServeFile(w, r, p)
+// lifecycleHandler returns a handler rendering the lifecycle state and events of the bootkit app.
+func lifecycleHandler(app *bootkit.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(app.Lifecycle()); err != nil {
+			logger.Ctx(r.Context()).Error("json.Encode failed", logger.WithError(err))
+		}
 	}
 }
 
@@ -195,8 +349,13 @@ func SetDefaultMarshalerOptions() gwruntime.ServeMuxOption {
 // RegisterForwardHeaders forwards permanent or specified headers from http request to gRPC server.
 // Check each `key` within specHeaders, or belong to the list of  permanent request headers maintained by IANA.
 // http://www.iana.org/assignments/message-headers/message-headers.xml
+// The Idempotency-Key and X-Request-Id headers are always forwarded.
 func RegisterForwardHeaders(specHeaders map[string]struct{}) gwruntime.ServeMuxOption {
+	idemKey, reqID := renderHeaderKey(IdempotencyKey), renderHeaderKey(RequestID)
 	return gwruntime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+		if key == idemKey || key == reqID {
+			return SpecifiedHeaderPrefix + key, true
+		}
 		if _, ok := specHeaders[key]; ok {
 			return SpecifiedHeaderPrefix + key, ok
 		}
@@ -207,9 +366,12 @@ func RegisterForwardHeaders(specHeaders map[string]struct{}) gwruntime.ServeMuxO
 }
 
 // RegisterResponseHeaders forwards specified gPRC headers (outgoingHeaders) to http server,
-// and inject into response headers.
+// and inject into response headers. Rate limit and Idempotent-Replayed headers are always forwarded.
 func RegisterResponseHeaders(outgoingHeaders map[string]struct{}) gwruntime.ServeMuxOption {
-	hs := map[string]struct{}{}
+	hs := map[string]struct{}{IdempotentReplayed: {}}
+	for _, k := range rateLimitHeaders {
+		hs[k] = struct{}{}
+	}
 	for k := range outgoingHeaders {
 		hs[strings.ToLower(k)] = struct{}{}
 	}
@@ -228,6 +390,7 @@ func enrichMetaData() gwruntime.ServeMuxOption {
 	return gwruntime.WithMetadata(func(ctx context.Context, r *http.Request) metadata.MD {
 		// TODO: inject the identity info based on the auth token.
 		route, _ := gwruntime.HTTPPathPattern(ctx)
+		setRoute(ctx, route)
 
 		return metadata.New(map[string]string{
 			HTTPMethod:     r.Method,
@@ -257,18 +420,6 @@ func OverrideResponseStatusCode() gwruntime.ServeMuxOption {
 	})
 }
 
-// RegisterHTTPErrorHandler registers customized http handler, overriding http code based on customized status code.
-func RegisterHTTPErrorHandler() gwruntime.ServeMuxOption {
-	return gwruntime.WithErrorHandler(func(ctx context.Context, mux *gwruntime.ServeMux, m gwruntime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
-		// override default status code based on native mechanism
-		if code, _ := extractStatusCode(ctx); code != 0 {
-			err = &gwruntime.HTTPStatusError{HTTPStatus: code, Err: err}
-		}
-
-		gwruntime.DefaultHTTPErrorHandler(ctx, mux, m, w, r, err)
-	})
-}
-
 // InjectHTTPCode injects the customized status code.
 func InjectHTTPCode(ctx context.Context, code int) error {
 	// TODO: if nedding to customized code, should allow more in the future
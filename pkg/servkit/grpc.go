@@ -2,62 +2,170 @@ package servkit
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
-	newrelic "github.com/newrelic/go-agent/v3/newrelic"
 	"google.golang.org/genproto/googleapis/api/httpbody"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"demo/pkg/bootkit"
 	"demo/pkg/logger"
+	"demo/pkg/redactkit"
+	"demo/pkg/telemetrykit"
+)
+
+var (
+	// ErrNotServing indicates the gRPC server is not serving.
+	ErrNotServing = errors.New("grpc server is not serving")
+	// ErrInvalidTLSConfig indicates the TLS options are incomplete.
+	ErrInvalidTLSConfig = errors.New("invalid tls config")
 )
 
 type RegisterServerFunc func(s *grpc.Server)
 
-// RunGrpcServer starts gRPC server
+// RunGrpcServer starts gRPC server listening at addr, which is a tcp address like ":8080",
+// or a unix domain socket like "unix:///var/run/grpc.sock".
 func RunGrpcServer(
 	ctx context.Context, addr string,
 	shutdown bootkit.ShutdownFunc,
 	registerServers RegisterServerFunc,
 	options ...ServOptions,
 ) error {
-	// TODO: only provide tcp now. Need to support unix models socket in the future.
-	lis, err := net.Listen("tcp", addr)
+	o := applyServOptions(options...)
+
+	l, err := newServCertLoader(ctx, o, "grpc-server-tls")
 	if err != nil {
-		logger.Ctx(ctx).Error("net.Listen failed",
+		return err
+	}
+	if l != nil {
+		o.grpcServOpts = append(o.grpcServOpts, grpc.Creds(credentials.NewTLS(l.serverConfig("h2"))))
+	}
+
+	lis, err := listen(addr)
+	if err != nil {
+		logger.Ctx(ctx).Error("listen failed",
 			logger.WithError(err),
 			logger.WithField("addr", addr),
 		)
 		return err
 	}
 
-	o := applyServOptions(options...)
-	o.grpcServOpts = append(o.grpcServOpts,
-		grpc.ChainUnaryInterceptor(enrichUnaryLogger, LoggingInterceptor),
-		grpc.ChainStreamInterceptor(enrichStreamLogger),
-	)
-	serv := grpc.NewServer(o.grpcServOpts...)
-	// register grpc related servers
-	registerServers(serv)
+	dr := newDrainer()
+	serv, hc := newGrpcServer(o, dr, registerServers)
+	serving := addServingProbe(o)
 
 	shutdown(func() error {
-		// close gRPC server
+		serving.Store(false)
+		hc.shutdown()
+		// close gRPC server, streams held open by clients are cut off once the drain timeout passes
 		logger.Info("Shutting down the gRPC server ...")
-		serv.GracefulStop()
+		_ = dr.stop("grpc-server", o.drainTimeout, func() error {
+			serv.GracefulStop()
+			return nil
+		}, serv.Stop)
 		// close listener
 		return lis.Close()
-	})
+	}, bootkit.WithShutdownName("grpc-server"))
 
 	logger.Ctx(ctx).Info(fmt.Sprintf("Starting gRPC server listening at %s", addr))
 
+	serving.Store(true)
+	go hc.run()
+	if o.inProcess != nil {
+		// serve the gateway in the same process, the listener is closed by GracefulStop() or Stop()
+		inLis := o.inProcess.listen()
+		go func() {
+			if err := serv.Serve(inLis); err != nil {
+				logger.Ctx(ctx).Error("in-process serv.Serve failed", logger.WithError(err))
+			}
+		}()
+	}
+	o.ready()
+
 	return serv.Serve(lis)
 }
 
+// newServCertLoader loads the certificate of the server, and reloads it on SIGHUP. It returns nil without TLS.
+func newServCertLoader(ctx context.Context, o *servOptions, name string) (*certLoader, error) {
+	if o.servTLS == nil {
+		return nil, nil
+	}
+
+	if o.servTLS.certFile == "" {
+		return nil, fmt.Errorf("%w: certificate is required, see WithTLS()", ErrInvalidTLSConfig)
+	}
+
+	l, err := newCertLoader(o.servTLS.certFile, o.servTLS.keyFile, o.servTLS.caFile)
+	if err != nil {
+		logger.Ctx(ctx).Error("newCertLoader failed", logger.WithError(err))
+		return nil, err
+	}
+	o.app.AddReloadHandler(name, l.Reload)
+
+	return l, nil
+}
+
+// grpcInterceptors returns the tracing, logging, metrics, draining, deadline, rate limiting, validation,
+// idempotency and recovery interceptors in order. Recovery runs right before the handler,
+// so the others observe a panic as codes.Internal.
+func grpcInterceptors(o *servOptions, dr *drainer) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
+	t := &tracer{provider: o.telemetry}
+	d := newDeadliner(o)
+	unary := []grpc.UnaryServerInterceptor{
+		t.unary, enrichUnaryLogger, LoggingInterceptor, MetricsInterceptor, dr.unary, d.unary,
+	}
+	stream := []grpc.StreamServerInterceptor{
+		t.stream, enrichStreamLogger, StreamLoggingInterceptor, MetricsStreamInterceptor, dr.stream, d.stream,
+	}
+	if len(o.rateLimits) > 0 {
+		l := &rateLimiter{rules: o.rateLimits}
+		unary = append(unary, l.unary)
+		stream = append(stream, l.stream)
+	}
+
+	unary = append(unary, ValidationInterceptor)
+	stream = append(stream, ValidationStreamInterceptor)
+	if len(o.idempotency) > 0 || o.defaultIdempotency != nil {
+		unary = append(unary, newIdempotency(o).unary)
+	}
+
+	return append(unary, RecoveryInterceptor), append(stream, RecoveryStreamInterceptor)
+}
+
+// newGrpcServer creates the gRPC server with the interceptors of grpcInterceptors(), and registers grpc related
+// servers, along with the health service and server reflection if they're enabled.
+func newGrpcServer(o *servOptions, dr *drainer, registerServers RegisterServerFunc) (*grpc.Server, *healthChecker) {
+	unary, stream := grpcInterceptors(o, dr)
+	o.grpcServOpts = append(o.grpcServOpts, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	serv := grpc.NewServer(o.grpcServOpts...)
+	registerServers(serv)
+
+	hc := registerHealth(o, serv)
+	registerReflection(o, serv)
+
+	return serv, hc
+}
+
+// addServingProbe registers the probe of the gRPC server, which passes once the returned flag is set.
+func addServingProbe(o *servOptions) *atomic.Bool {
+	serving := &atomic.Bool{}
+	o.app.AddProbe("grpc-server", func(ctx context.Context) error {
+		if !serving.Load() {
+			return ErrNotServing
+		}
+		return nil
+	})
+
+	return serving
+}
+
 func enrichUnaryLogger(
 	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
 ) (interface{}, error) {
@@ -70,20 +178,7 @@ func enrichUnaryLogger(
 		return handler(ctx, req)
 	}
 
-	var nrTraceID, nrSpanID string
-	if txn := newrelic.FromContext(ctx); txn != nil {
-		nrMD := txn.GetTraceMetadata()
-		nrTraceID = nrMD.TraceID
-		nrSpanID = nrMD.SpanID
-	}
-
-	if nrTraceID == "" {
-		nrTraceID = strings.Replace(uuid.NewString(), "-", "", -1)
-	}
-	if nrSpanID == "" {
-		nrSpanID = strings.Replace(uuid.NewString(), "-", "", -1)
-	}
-
+	nrTraceID, nrSpanID := traceIDs(ctx)
 	ctx = logger.ContextWithFields(ctx, logger.Fields{
 		"http.method":     md.Method(),
 		"http.requestURI": md.RequestURI(),
@@ -111,13 +206,7 @@ func enrichStreamLogger(
 	wss := newStreamContextWrapper(ss)
 
 	ctx := wss.Context()
-	var nrTraceID, nrSpanID string
-	if txn := newrelic.FromContext(ctx); txn != nil {
-		nrMD := txn.GetTraceMetadata()
-		nrTraceID = nrMD.TraceID
-		nrSpanID = nrMD.SpanID
-	}
-
+	nrTraceID, nrSpanID := traceIDs(ctx)
 	ctx = logger.ContextWithFields(ctx, logger.Fields{
 		"http.method":     md.Method(),
 		"http.requestURI": md.RequestURI(),
@@ -131,6 +220,20 @@ func enrichStreamLogger(
 	return handler(srv, wss)
 }
 
+// traceIDs returns IDs of the trace and the span from the active telemetry provider,
+// or generates them if the provider doesn't assign any so that logs of the same request can be correlated.
+func traceIDs(ctx context.Context) (traceID, spanID string) {
+	traceID, spanID = telemetrykit.TraceIDs(ctx)
+	if traceID == "" {
+		traceID = strings.Replace(uuid.NewString(), "-", "", -1)
+	}
+	if spanID == "" {
+		spanID = strings.Replace(uuid.NewString(), "-", "", -1)
+	}
+
+	return traceID, spanID
+}
+
 type StreamContextWrapper interface {
 	grpc.ServerStream
 	SetContext(context.Context)
@@ -157,6 +260,7 @@ func newStreamContextWrapper(inner grpc.ServerStream) StreamContextWrapper {
 	}
 }
 
+// LoggingInterceptor logs requests and responses, masking sensitive fields by redactkit.
 func LoggingInterceptor(
 	ctx context.Context,
 	req interface{},
@@ -167,26 +271,93 @@ func LoggingInterceptor(
 	method := reqMd["spec-http-method"]
 	apiRoute := reqMd["spec-http-route"]
 
+	now := time.Now()
 	resp, err := handler(ctx, req)
 
+	fs := logger.Fields{
+		"grpc.method": info.FullMethod,
+		"grpc.code":   status.Code(err).String(),
+		"duration":    time.Since(now).String(),
+	}
 	if err != nil {
-		logger.Ctx(ctx).Error(fmt.Sprintf("API log: %s %s, Error: %v , Request: %+v", method, apiRoute, err, req))
+		logger.Ctx(ctx).Error(fmt.Sprintf("API log: %s %s, Error: %v , Request: %s", method, apiRoute, err, redactkit.Message(req)),
+			logger.WithError(err),
+			logger.WithFields(fs),
+		)
 	} else {
-		var ret []byte
-		var err error
-
+		var ret string
 		if httpBody, ok := resp.(*httpbody.HttpBody); ok {
-			ret = httpBody.Data
+			ret = redactkit.Body(httpBody.GetContentType(), httpBody.GetData())
 		} else {
-			ret, err = json.Marshal(resp)
+			ret = redactkit.Message(resp)
 		}
 
-		if err != nil {
-			logger.Ctx(ctx).Info(fmt.Sprintf("API log: %s %s, Request: %+v, Response: %+v", method, apiRoute, req, resp))
-		} else {
-			logger.Ctx(ctx).Info(fmt.Sprintf("API log: %s %s, Request: %+v, Response: %+v", method, apiRoute, req, string(ret)))
-		}
+		logger.Ctx(ctx).Info(fmt.Sprintf("API log: %s %s, Request: %s, Response: %s", method, apiRoute, redactkit.Message(req), ret),
+			logger.WithFields(fs),
+		)
 	}
 
 	return resp, err
 }
+
+// StreamLoggingInterceptor logs the start and the end of streams with their duration, message counts
+// in each direction and the final status, using the same field names as LoggingInterceptor.
+func StreamLoggingInterceptor(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	ctx := ss.Context()
+	reqMd, _ := metadata.FromIncomingContext(ctx)
+	method := reqMd["spec-http-method"]
+	apiRoute := reqMd["spec-http-route"]
+
+	logger.Ctx(ctx).Info(fmt.Sprintf("API stream started: %s %s", method, apiRoute), logger.WithFields(logger.Fields{
+		"grpc.method":       info.FullMethod,
+		"grpc.clientStream": info.IsClientStream,
+		"grpc.serverStream": info.IsServerStream,
+	}))
+
+	now := time.Now()
+	cs := &countingStream{ServerStream: ss}
+	err := handler(srv, cs)
+
+	fs := logger.Fields{
+		"grpc.method":   info.FullMethod,
+		"grpc.code":     status.Code(err).String(),
+		"duration":      time.Since(now).String(),
+		"grpc.recvMsgs": cs.recv.Load(),
+		"grpc.sentMsgs": cs.sent.Load(),
+	}
+	if err != nil {
+		logger.Ctx(ctx).Error(fmt.Sprintf("API stream log: %s %s, Error: %v", method, apiRoute, err),
+			logger.WithError(err),
+			logger.WithFields(fs),
+		)
+	} else {
+		logger.Ctx(ctx).Info(fmt.Sprintf("API stream log: %s %s", method, apiRoute), logger.WithFields(fs))
+	}
+
+	return err
+}
+
+// countingStream counts messages received from and sent to the client.
+type countingStream struct {
+	grpc.ServerStream
+
+	recv, sent atomic.Int64
+}
+
+func (s *countingStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
+
+func (s *countingStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.recv.Add(1)
+	}
+	return err
+}
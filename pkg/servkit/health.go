@@ -0,0 +1,128 @@
+package servkit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"demo/pkg/bootkit"
+)
+
+const (
+	defaultHealthInterval = 5 * time.Second
+)
+
+// healthChecker updates the serving status of grpc.health.v1.Health based on readiness probes of bootkit.
+type healthChecker struct {
+	srv      *health.Server
+	app      *bootkit.App
+	interval time.Duration
+	// services maps names of services to the probes they depend on, nil means all probes.
+	services map[string][]string
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// registerHealth registers the health service into the gRPC server if it's enabled by WithHealth().
+// It should be called after all services are registered, each of them gets a serving status.
+func registerHealth(o *servOptions, serv *grpc.Server) *healthChecker {
+	if o.healthInterval == 0 {
+		return nil
+	}
+
+	h := &healthChecker{
+		srv:      health.NewServer(),
+		app:      o.app,
+		interval: o.healthInterval,
+		services: map[string][]string{"": nil},
+		stop:     make(chan struct{}),
+	}
+	for name := range serv.GetServiceInfo() {
+		h.services[name] = nil
+	}
+	for name, probes := range o.healthDeps {
+		h.services[name] = probes
+	}
+	// not serving until the server starts
+	for name := range h.services {
+		h.srv.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+
+	healthpb.RegisterHealthServer(serv, h.srv)
+
+	return h
+}
+
+// run updates the serving status periodically until shutdown.
+func (h *healthChecker) run() {
+	if h == nil {
+		return
+	}
+
+	t := time.NewTicker(h.interval)
+	defer t.Stop()
+
+	for {
+		h.update(context.Background())
+
+		select {
+		case <-t.C:
+		case <-h.stop:
+			return
+		}
+	}
+}
+
+func (h *healthChecker) update(ctx context.Context) {
+	report := h.app.CheckReadiness(ctx)
+
+	checks := map[string]bool{}
+	for _, c := range report.Checks {
+		checks[c.Name] = c.Status == bootkit.ProbeStatusOK
+	}
+
+	for name, probes := range h.services {
+		serving := report.Healthy()
+		if probes != nil {
+			serving = !h.app.ShuttingDown()
+			for _, p := range probes {
+				serving = serving && checks[p]
+			}
+		}
+
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if serving {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		h.srv.SetServingStatus(name, status)
+	}
+}
+
+// shutdown sets all services to NOT_SERVING and stops updating, which is called once shutdown starts.
+func (h *healthChecker) shutdown() {
+	if h == nil {
+		return
+	}
+
+	h.stopOnce.Do(func() {
+		close(h.stop)
+		h.srv.Shutdown()
+	})
+}
+
+// registerReflection registers server reflection if it's enabled in the current namespace by WithReflection().
+func registerReflection(o *servOptions, serv *grpc.Server) {
+	if o.reflectionEnvs == nil {
+		return
+	}
+
+	if enabledIn(o.reflectionEnvs) {
+		reflection.Register(serv)
+	}
+}
@@ -0,0 +1,190 @@
+package servkit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"demo/pkg/bootkit"
+	"demo/pkg/envkit"
+	examplePb "demo/proto/example"
+)
+
+type healthSuite struct {
+	suite.Suite
+}
+
+func (s *healthSuite) SetupSuite()    {}
+func (s *healthSuite) TearDownSuite() {}
+func (s *healthSuite) SetupTest()     {}
+func (s *healthSuite) TearDownTest()  {}
+
+func TestHealthSuite(t *testing.T) {
+	suite.Run(t, new(healthSuite))
+}
+
+func (s *healthSuite) TestHealth() {
+	var mysqlBroken, cacheBroken atomic.Bool
+
+	app := bootkit.New()
+	app.AddProbe("mysql", func(context.Context) error {
+		if mysqlBroken.Load() {
+			return errors.New("broken")
+		}
+		return nil
+	})
+	app.AddProbe("cache", func(context.Context) error {
+		if cacheBroken.Load() {
+			return errors.New("broken")
+		}
+		return nil
+	}, bootkit.WithProbeCritical(false))
+
+	addr := "unix://" + filepath.Join(s.T().TempDir(), "grpc.sock")
+	app.RegisterServer("grpc-server", func(shutdown bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
+		return RunGrpcServer(context.Background(), addr, shutdown,
+			func(serv *grpc.Server) {
+				examplePb.RegisterExampleServer(serv, examplePb.UnimplementedExampleServer{})
+			},
+			WithBootApp(app),
+			WithReady(ready),
+			WithHealth(10*time.Millisecond),
+			WithHealthDependencies("cache.Cache", "cache"),
+		)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(app.Run(ctx))
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	s.Require().NoError(err)
+	defer conn.Close()
+	cli := healthpb.NewHealthClient(conn)
+
+	expect := func(service string, exp healthpb.HealthCheckResponse_ServingStatus) {
+		s.Require().Eventually(func() bool {
+			resp, err := cli.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			return err == nil && resp.GetStatus() == exp
+		}, time.Second, 10*time.Millisecond, service)
+	}
+
+	expect("", healthpb.HealthCheckResponse_SERVING)
+	expect("example.Example", healthpb.HealthCheckResponse_SERVING)
+	expect("cache.Cache", healthpb.HealthCheckResponse_SERVING)
+
+	// a non-critical probe affects the services depending on it only
+	cacheBroken.Store(true)
+	expect("cache.Cache", healthpb.HealthCheckResponse_NOT_SERVING)
+	expect("example.Example", healthpb.HealthCheckResponse_SERVING)
+
+	cacheBroken.Store(false)
+	mysqlBroken.Store(true)
+	expect("", healthpb.HealthCheckResponse_NOT_SERVING)
+	expect("example.Example", healthpb.HealthCheckResponse_NOT_SERVING)
+	expect("cache.Cache", healthpb.HealthCheckResponse_SERVING)
+
+	cancel()
+	app.Wait()
+}
+
+func (s *healthSuite) TestReflection() {
+	tests := []struct {
+		Desc      string
+		Namespace string
+		Options   []ServOptions
+		ExpExist  bool
+	}{
+		{
+			Desc:      "disabled by default",
+			Namespace: "development",
+			ExpExist:  false,
+		},
+		{
+			Desc:      "enabled except production",
+			Namespace: "development",
+			Options:   []ServOptions{WithReflection()},
+			ExpExist:  true,
+		},
+		{
+			Desc:      "disabled in production",
+			Namespace: "production",
+			Options:   []ServOptions{WithReflection()},
+			ExpExist:  false,
+		},
+		{
+			Desc:      "enabled in the given namespace",
+			Namespace: "production",
+			Options:   []ServOptions{WithReflection(envkit.EnvProduction)},
+			ExpExist:  true,
+		},
+		{
+			Desc:      "disabled in other namespaces",
+			Namespace: "development",
+			Options:   []ServOptions{WithReflection(envkit.EnvStaging)},
+			ExpExist:  false,
+		},
+	}
+
+	for _, t := range tests {
+		s.T().Setenv("ENV_NAMESPACE", t.Namespace)
+
+		o := applyServOptions(append(t.Options, WithBootApp(bootkit.New()))...)
+		serv, _ := newGrpcServer(o, newDrainer(), func(*grpc.Server) {})
+
+		_, ok := serv.GetServiceInfo()["grpc.reflection.v1.ServerReflection"]
+		s.Require().Equal(t.ExpExist, ok, t.Desc)
+	}
+}
+
+func (s *healthSuite) TestLifecycleEndpoint() {
+	tests := []struct {
+		Desc      string
+		Namespace string
+		Options   []ServOptions
+		ExpCode   int
+	}{
+		{
+			Desc:      "enabled by default",
+			Namespace: "development",
+			ExpCode:   http.StatusOK,
+		},
+		{
+			Desc:      "disabled in production by default",
+			Namespace: "production",
+			ExpCode:   http.StatusNotFound,
+		},
+		{
+			Desc:      "enabled in production",
+			Namespace: "production",
+			Options:   []ServOptions{WithLifecycleEndpoint(envkit.EnvProduction)},
+			ExpCode:   http.StatusOK,
+		},
+		{
+			Desc:      "disabled in other namespaces",
+			Namespace: "development",
+			Options:   []ServOptions{WithLifecycleEndpoint(envkit.EnvProduction)},
+			ExpCode:   http.StatusNotFound,
+		},
+	}
+
+	for _, t := range tests {
+		s.T().Setenv("ENV_NAMESPACE", t.Namespace)
+
+		cli, stop := serveExample(&s.Suite, s.T().TempDir(), t.Options...)
+		resp, err := cli.Get("http://localhost/debug/lifecycle")
+		s.Require().NoError(err, t.Desc)
+		resp.Body.Close()
+		stop()
+
+		s.Require().Equal(t.ExpCode, resp.StatusCode, t.Desc)
+	}
+}
@@ -0,0 +1,323 @@
+package servkit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"demo/pkg/idempotkit"
+	"demo/pkg/logger"
+)
+
+// headers of idempotent requests, which are forwarded as they are by the gateway.
+const (
+	// IdempotencyKey makes retries of a request replay its first response.
+	IdempotencyKey = "idempotency-key"
+	// IdempotentReplayed is set to "true" in responses replayed from the store.
+	IdempotentReplayed = "idempotent-replayed"
+)
+
+const (
+	// maxIdempotencyKeyLen bounds the length of keys given by clients.
+	maxIdempotencyKeyLen = 255
+	// defaultIdempotencyLock is how long a key is reserved if the request has no deadline,
+	// so the key of a crashed request can be taken over eventually.
+	defaultIdempotencyLock = time.Minute
+)
+
+var (
+	errIdempotencyInFlight = status.Error(codes.Aborted, "request with the same idempotency key is in flight")
+	errIdempotencyMismatch = status.Error(codes.InvalidArgument, "idempotency key is reused for a different request")
+	errIdempotencyKeyLen   = status.Error(codes.InvalidArgument, "idempotency key is too long")
+	errIdempotencyStore    = status.Error(codes.Unavailable, "idempotency store is unavailable")
+)
+
+// idempotencyRule keeps the responses of a method in the store for ttl.
+type idempotencyRule struct {
+	store idempotkit.Store
+	ttl   time.Duration
+}
+
+// idempotency replays the first response of unary requests with the same idempotency key,
+// and rejects the duplicates while the first one is in flight.
+// Rules of WithIdempotency() with methods take precedence over the one without methods.
+type idempotency struct {
+	methods  map[string]idempotencyRule
+	fallback *idempotencyRule
+}
+
+func newIdempotency(o *servOptions) *idempotency {
+	return &idempotency{methods: o.idempotency, fallback: o.defaultIdempotency}
+}
+
+func (i *idempotency) rule(method string) (idempotencyRule, bool) {
+	if r, ok := i.methods[method]; ok {
+		return r, true
+	}
+	if i.fallback != nil {
+		return *i.fallback, true
+	}
+
+	return idempotencyRule{}, false
+}
+
+func (i *idempotency) unary(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	r, ok := i.rule(info.FullMethod)
+	if !ok {
+		return handler(ctx, req)
+	}
+	key := idempotencyKey(ctx)
+	if key == "" {
+		return handler(ctx, req)
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		return nil, errIdempotencyKeyLen
+	}
+
+	// keys are scoped by the method and the identity, so clients can't get responses of others.
+	// keys of anonymous requests are scoped by the payload instead, so only the same request is replayed.
+	fp := fingerprint(req)
+	identity := KeyByIdentity()(ctx, info.FullMethod)
+	if identity == "" {
+		identity = "anonymous:" + fp
+	}
+	key = hashKey(strings.Join([]string{info.FullMethod, identity, key}, "|"))
+
+	stored, err := r.store.Reserve(ctx, key, idempotencyLock(ctx))
+	switch {
+	case errors.Is(err, idempotkit.ErrInFlight):
+		return nil, errIdempotencyInFlight
+	case err != nil:
+		logger.Ctx(ctx).Error("store.Reserve failed",
+			logger.WithError(err),
+			logger.WithField("grpc.method", info.FullMethod),
+		)
+		return nil, errIdempotencyStore
+	case stored != nil:
+		return replayIdempotent(ctx, stored, fp)
+	}
+
+	rec := &headerRecorder{ServerTransportStream: grpc.ServerTransportStreamFromContext(ctx)}
+	resp, err := handler(grpc.NewContextWithServerTransportStream(ctx, rec), req)
+
+	// save the response even if the request is cancelled in the meantime
+	sctx := context.WithoutCancel(ctx)
+	if retryable(err) {
+		i.release(sctx, r, key, info.FullMethod)
+		return resp, err
+	}
+
+	data, serr := encodeIdempotent(fp, rec, resp, err)
+	if serr == nil {
+		serr = r.store.Complete(sctx, key, data, r.ttl)
+	}
+	if serr != nil {
+		logger.Ctx(ctx).Error("store.Complete failed",
+			logger.WithError(serr),
+			logger.WithField("grpc.method", info.FullMethod),
+		)
+		i.release(sctx, r, key, info.FullMethod)
+	}
+
+	return resp, err
+}
+
+func (i *idempotency) release(ctx context.Context, r idempotencyRule, key, method string) {
+	if err := r.store.Release(ctx, key); err != nil {
+		logger.Ctx(ctx).Error("store.Release failed",
+			logger.WithError(err),
+			logger.WithField("grpc.method", method),
+		)
+	}
+}
+
+// idempotencyKey returns the key forwarded by the gateway, or the one in the metadata of native gRPC requests.
+func idempotencyKey(ctx context.Context) string {
+	md, ok := GetMetadata(ctx)
+	if !ok {
+		return ""
+	}
+
+	if v := md.Header(IdempotencyKey); v != "" {
+		return v
+	}
+	return md.getSpecKey(IdempotencyKey)
+}
+
+// idempotencyLock reserves the key until the deadline of the request.
+func idempotencyLock(ctx context.Context) time.Duration {
+	dl, ok := ctx.Deadline()
+	if !ok {
+		return defaultIdempotencyLock
+	}
+
+	return max(time.Until(dl), time.Second)
+}
+
+// retryable reports whether the request failed transiently, whose key is released for retries
+// instead of keeping the failure.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Canceled, codes.Unknown, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted,
+		codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+
+	return false
+}
+
+// fingerprint identifies the payload of the request, so a key can't be reused for a different one.
+func fingerprint(req interface{}) string {
+	m, ok := req.(proto.Message)
+	if !ok {
+		return ""
+	}
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// idempotentRecord is the first response of a request kept in the store.
+type idempotentRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Header      metadata.MD `json:"header,omitempty"`
+	Trailer     metadata.MD `json:"trailer,omitempty"`
+	// Status is the google.rpc.Status of a failed request
+	Status []byte `json:"status,omitempty"`
+	// Response is the google.protobuf.Any of the response message
+	Response []byte `json:"response,omitempty"`
+}
+
+func encodeIdempotent(fp string, rec *headerRecorder, resp interface{}, err error) ([]byte, error) {
+	r := idempotentRecord{
+		Fingerprint: fp,
+		Header:      rec.header,
+		Trailer:     rec.trailer,
+	}
+
+	if err != nil {
+		b, merr := proto.Marshal(status.Convert(err).Proto())
+		if merr != nil {
+			return nil, merr
+		}
+		r.Status = b
+	} else if m, ok := resp.(proto.Message); ok {
+		a, aerr := anypb.New(m)
+		if aerr != nil {
+			return nil, aerr
+		}
+		b, merr := proto.Marshal(a)
+		if merr != nil {
+			return nil, merr
+		}
+		r.Response = b
+	}
+
+	return json.Marshal(r)
+}
+
+// replayIdempotent returns the stored response along with its headers.
+func replayIdempotent(ctx context.Context, data []byte, fp string) (interface{}, error) {
+	var r idempotentRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		logger.Ctx(ctx).Error("json.Unmarshal failed", logger.WithError(err))
+		return nil, errIdempotencyStore
+	}
+	if r.Fingerprint != fp {
+		return nil, errIdempotencyMismatch
+	}
+
+	if err := grpc.SetHeader(ctx, metadata.Join(r.Header, metadata.Pairs(IdempotentReplayed, "true"))); err != nil {
+		logger.Ctx(ctx).Error("grpc.SetHeader failed", logger.WithError(err))
+	}
+	if len(r.Trailer) > 0 {
+		if err := grpc.SetTrailer(ctx, r.Trailer); err != nil {
+			logger.Ctx(ctx).Error("grpc.SetTrailer failed", logger.WithError(err))
+		}
+	}
+
+	if r.Status != nil {
+		st := &spb.Status{}
+		if err := proto.Unmarshal(r.Status, st); err != nil {
+			logger.Ctx(ctx).Error("proto.Unmarshal failed", logger.WithError(err))
+			return nil, errIdempotencyStore
+		}
+		return nil, status.ErrorProto(st)
+	}
+
+	a := &anypb.Any{}
+	if err := proto.Unmarshal(r.Response, a); err != nil {
+		logger.Ctx(ctx).Error("proto.Unmarshal failed", logger.WithError(err))
+		return nil, errIdempotencyStore
+	}
+	m, err := a.UnmarshalNew()
+	if err != nil {
+		logger.Ctx(ctx).Error("anypb.UnmarshalNew failed", logger.WithError(err))
+		return nil, errIdempotencyStore
+	}
+
+	return m, nil
+}
+
+// headerRecorder records the headers and trailers set by the handler, and passes them through.
+type headerRecorder struct {
+	grpc.ServerTransportStream
+
+	header, trailer metadata.MD
+}
+
+func (r *headerRecorder) SetHeader(md metadata.MD) error {
+	r.header = metadata.Join(r.header, md)
+	if r.ServerTransportStream == nil {
+		return nil
+	}
+	return r.ServerTransportStream.SetHeader(md)
+}
+
+func (r *headerRecorder) SendHeader(md metadata.MD) error {
+	r.header = metadata.Join(r.header, md)
+	if r.ServerTransportStream == nil {
+		return nil
+	}
+	return r.ServerTransportStream.SendHeader(md)
+}
+
+func (r *headerRecorder) SetTrailer(md metadata.MD) error {
+	r.trailer = metadata.Join(r.trailer, md)
+	if r.ServerTransportStream == nil {
+		return nil
+	}
+	return r.ServerTransportStream.SetTrailer(md)
+}
+
+// idempotencyMiddleware drops the idempotency key of safe HTTP methods, which are idempotent already
+// and shouldn't be served from the store.
+func idempotencyMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			r.Header.Del(IdempotencyKey)
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
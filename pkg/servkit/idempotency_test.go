@@ -0,0 +1,296 @@
+package servkit
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"demo/pkg/idempotkit"
+	pb "demo/proto/example"
+)
+
+type idempotencySuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (s *idempotencySuite) SetupSuite() {
+	dir, err := os.MkdirTemp("", "idempotency")
+	s.Require().NoError(err)
+	s.dir = dir
+}
+
+func (s *idempotencySuite) TearDownSuite() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *idempotencySuite) SetupTest()    {}
+func (s *idempotencySuite) TearDownTest() {}
+
+func TestIdempotencySuite(t *testing.T) {
+	suite.Run(t, new(idempotencySuite))
+}
+
+func (s *idempotencySuite) TestInterceptor() {
+	const method = "/example.Example/Login"
+	type call struct {
+		Key string
+		// Auth is the authorization of the caller, "Bearer user" if empty
+		Auth      string
+		Anonymous bool
+		Req       *pb.LoginReq
+		ExpResp   proto.Message
+		ExpCode   codes.Code
+	}
+	req := &pb.LoginReq{Username: "user", Password: "pass"}
+	other := &pb.LoginReq{Username: "other", Password: "pass"}
+
+	tests := []struct {
+		Desc     string
+		Methods  []string
+		Err      error
+		Calls    []call
+		ExpCalls int32
+	}{
+		{
+			Desc: "replay the response",
+			Calls: []call{
+				{Key: "k1", Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+				{Key: "k1", Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+				{Key: "k2", Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+			},
+			ExpCalls: 2,
+		},
+		{
+			Desc: "replay the failure",
+			Err:  status.Error(codes.PermissionDenied, "denied"),
+			Calls: []call{
+				{Key: "k1", Req: req, ExpCode: codes.PermissionDenied},
+				{Key: "k1", Req: req, ExpCode: codes.PermissionDenied},
+			},
+			ExpCalls: 1,
+		},
+		{
+			Desc: "retry the transient failure",
+			Err:  status.Error(codes.Unavailable, "unavailable"),
+			Calls: []call{
+				{Key: "k1", Req: req, ExpCode: codes.Unavailable},
+				{Key: "k1", Req: req, ExpCode: codes.Unavailable},
+			},
+			ExpCalls: 2,
+		},
+		{
+			Desc: "reuse the key for a different request",
+			Calls: []call{
+				{Key: "k1", Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+				{Key: "k1", Req: other, ExpCode: codes.InvalidArgument},
+			},
+			ExpCalls: 1,
+		},
+		{
+			Desc: "without the key",
+			Calls: []call{
+				{Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+				{Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+			},
+			ExpCalls: 2,
+		},
+		{
+			Desc: "scoped by the caller",
+			Calls: []call{
+				{Key: "k1", Auth: "Bearer a", Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+				{Key: "k1", Auth: "Bearer b", Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+				{Key: "k1", Auth: "Bearer a", Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+			},
+			ExpCalls: 2,
+		},
+		{
+			Desc: "replay the anonymous request",
+			Calls: []call{
+				{Key: "k1", Anonymous: true, Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+				{Key: "k1", Anonymous: true, Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+			},
+			ExpCalls: 1,
+		},
+		{
+			Desc: "anonymous callers",
+			Calls: []call{
+				{Key: "k1", Anonymous: true, Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+				{Key: "k1", Anonymous: true, Req: other, ExpResp: &pb.LoginResp{Status: "other"}},
+			},
+			ExpCalls: 2,
+		},
+		{
+			Desc: "key too long",
+			Calls: []call{
+				{Key: strings.Repeat("k", maxIdempotencyKeyLen+1), Req: req, ExpCode: codes.InvalidArgument},
+			},
+		},
+		{
+			Desc:    "other methods",
+			Methods: []string{"/example.Example/ListItems"},
+			Calls: []call{
+				{Key: "k1", Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+				{Key: "k1", Req: req, ExpResp: &pb.LoginResp{Status: "user"}},
+			},
+			ExpCalls: 2,
+		},
+	}
+
+	for _, t := range tests {
+		o := applyServOptions(WithIdempotency(idempotkit.NewMemoryStore(), time.Hour, t.Methods...))
+		i := newIdempotency(o)
+
+		var calls int32
+		handler := func(_ context.Context, req interface{}) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			if t.Err != nil {
+				return nil, t.Err
+			}
+			return &pb.LoginResp{Status: req.(*pb.LoginReq).Username}, nil
+		}
+
+		for n, c := range t.Calls {
+			md := metadata.Pairs(IdempotencyKey, c.Key)
+			if !c.Anonymous {
+				md.Set("authorization", cmp.Or(c.Auth, "Bearer user"))
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+			resp, err := i.unary(ctx, c.Req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+			s.Require().Equal(c.ExpCode, status.Code(err), t.Desc, n)
+			if c.ExpResp != nil {
+				s.Require().True(proto.Equal(c.ExpResp, resp.(proto.Message)), t.Desc, n)
+			}
+		}
+		s.Require().Equal(t.ExpCalls, calls, t.Desc)
+	}
+}
+
+func (s *idempotencySuite) TestInFlight() {
+	i := newIdempotency(applyServOptions(WithIdempotency(idempotkit.NewMemoryStore(), time.Hour)))
+	info := &grpc.UnaryServerInfo{FullMethod: "/example.Example/Login"}
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(IdempotencyKey, "k1", "authorization", "Bearer user"))
+
+	started, finish := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := i.unary(ctx, &pb.LoginReq{}, info, func(context.Context, interface{}) (interface{}, error) {
+			close(started)
+			<-finish
+			return &pb.LoginResp{Status: "success"}, nil
+		})
+		done <- err
+	}()
+	<-started
+
+	_, err := i.unary(ctx, &pb.LoginReq{}, info, func(context.Context, interface{}) (interface{}, error) {
+		s.FailNow("duplicate request is handled")
+		return nil, nil
+	})
+	s.Require().Equal(codes.Aborted, status.Code(err))
+
+	close(finish)
+	s.Require().NoError(<-done)
+}
+
+func (s *idempotencySuite) TestGateway() {
+	store := idempotkit.NewMemoryStore()
+	cli, stop := serveExample(&s.Suite, s.dir, WithIdempotency(store, time.Hour))
+	defer stop()
+
+	post := func(key, auth string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost/v1/login",
+			strings.NewReader(`{"Username":"user","Password":"pass"}`))
+		s.Require().NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+
+		resp, err := cli.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+
+	tests := []struct {
+		Desc        string
+		Key         string
+		Auth        string
+		ExpCode     int
+		ExpReplayed string
+	}{
+		{
+			Desc:    "first request",
+			Key:     "k1",
+			Auth:    "Bearer user",
+			ExpCode: http.StatusOK,
+		},
+		{
+			Desc:        "retried request",
+			Key:         "k1",
+			Auth:        "Bearer user",
+			ExpCode:     http.StatusOK,
+			ExpReplayed: "true",
+		},
+		{
+			Desc:    "another caller",
+			Key:     "k1",
+			Auth:    "Bearer other",
+			ExpCode: http.StatusOK,
+		},
+		{
+			Desc:    "duplicate in flight",
+			Key:     "k2",
+			Auth:    "Bearer user",
+			ExpCode: http.StatusConflict,
+		},
+		{
+			Desc:    "anonymous caller",
+			Key:     "k3",
+			ExpCode: http.StatusOK,
+		},
+		{
+			Desc:        "retried anonymous request",
+			Key:         "k3",
+			ExpCode:     http.StatusOK,
+			ExpReplayed: "true",
+		},
+	}
+
+	// k2 is held by a request in flight
+	_, err := store.Reserve(context.Background(),
+		hashKey("/example.Example/Login|"+hashKey("Bearer user")+"|k2"), time.Minute)
+	s.Require().NoError(err)
+
+	var first []byte
+	for _, t := range tests {
+		resp := post(t.Key, t.Auth)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		s.Require().NoError(err, t.Desc)
+		s.Require().Equal(t.ExpCode, resp.StatusCode, t.Desc)
+		s.Require().Equal(t.ExpReplayed, resp.Header.Get("Idempotent-Replayed"), t.Desc)
+
+		if first == nil {
+			first = body
+		} else if t.ExpCode == http.StatusOK {
+			s.Require().Equal(string(first), string(body), t.Desc)
+		}
+	}
+}
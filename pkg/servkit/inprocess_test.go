@@ -0,0 +1,99 @@
+package servkit
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"demo/pkg/bootkit"
+	pb "demo/proto/example"
+)
+
+type inProcessSuite struct {
+	suite.Suite
+}
+
+func (s *inProcessSuite) SetupSuite()    {}
+func (s *inProcessSuite) TearDownSuite() {}
+func (s *inProcessSuite) SetupTest()     {}
+func (s *inProcessSuite) TearDownTest()  {}
+
+func TestInProcessSuite(t *testing.T) {
+	suite.Run(t, new(inProcessSuite))
+}
+
+// peerServer replies the peer address and the route enriched by the gateway.
+type peerServer struct {
+	*pb.UnimplementedExampleServer
+}
+
+func (peerServer) Login(ctx context.Context, _ *pb.LoginReq) (*pb.LoginResp, error) {
+	p, _ := peer.FromContext(ctx)
+	md, _ := GetMetadata(ctx)
+	return &pb.LoginResp{Status: p.Addr.String(), Description: md.Route()}, nil
+}
+
+func (s *inProcessSuite) TestGateway() {
+	dir := s.T().TempDir()
+	grpcSock, gwSock := filepath.Join(dir, "grpc.sock"), filepath.Join(dir, "gw.sock")
+	inProcess := NewInProcess()
+
+	app := bootkit.New()
+	app.RegisterServer("grpc-server", func(shutdown bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
+		return RunGrpcServer(context.Background(), "unix://"+grpcSock, shutdown,
+			func(serv *grpc.Server) {
+				pb.RegisterExampleServer(serv, peerServer{})
+			},
+			WithBootApp(app), WithReady(ready), WithInProcess(inProcess),
+		)
+	})
+	app.RegisterServer("grpc-gateway", func(shutdown bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
+		// the address is never dialed
+		return RunGrpcGateway(context.Background(), "unix://"+gwSock, "unix:///nonexistent.sock", shutdown,
+			func(ctx context.Context, mux *gwruntime.ServeMux, conn *grpc.ClientConn) error {
+				return pb.RegisterExampleHandler(ctx, mux, conn)
+			},
+			WithBootApp(app), WithReady(ready), WithInProcess(inProcess),
+		)
+	}, bootkit.WithDependsOn("grpc-server"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(app.Run(ctx))
+	defer func() {
+		cancel()
+		app.Wait()
+	}()
+
+	cli := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", gwSock)
+			},
+		},
+		Timeout: time.Second,
+	}
+
+	resp, err := cli.Post("http://localhost/v1/login", "application/json",
+		strings.NewReader(`{"Username":"user","Password":"pass"}`))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	b, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+	var body pb.LoginResp
+	s.Require().NoError(protojson.Unmarshal(b, &body))
+	s.Require().Equal("bufconn", body.Status)
+	s.Require().Equal("/v1/login", body.Description)
+}
@@ -0,0 +1,40 @@
+package servkit
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+const (
+	// unixScheme is the prefix of unix domain socket addresses, ex: unix:///var/run/grpc.sock
+	unixScheme = "unix://"
+)
+
+var (
+	// ErrInvalidAddr indicates the address can't be listened on.
+	ErrInvalidAddr = errors.New("invalid address")
+)
+
+// listen announces on tcp addresses like ":8080", or unix domain sockets like "unix:///var/run/grpc.sock".
+// A stale socket file left by the previous process is removed before listening.
+func listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, unixScheme) {
+		return net.Listen("tcp", addr)
+	}
+
+	path := strings.TrimPrefix(addr, unixScheme)
+	if path == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddr, addr)
+	}
+
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return net.Listen("unix", path)
+}
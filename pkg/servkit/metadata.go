@@ -63,6 +63,11 @@ func (md *MD) Authorization() string {
 	return md.getSpecKey(HTTPAuthorization)
 }
 
+// RequestID returns the X-Request-Id forwarded by the gateway.
+func (md *MD) RequestID() string {
+	return md.getSpecKey(SpecifiedHeaderPrefix + RequestID)
+}
+
 func (md *MD) Header(key string) string {
 	return md.getPartalKey(key)
 }
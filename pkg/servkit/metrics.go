@@ -0,0 +1,119 @@
+package servkit
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"demo/pkg/metrickit"
+)
+
+// MetricsInterceptor feeds metrickit with the count, latency and status code of unary RPCs.
+func MetricsInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	done := metrickit.StartGRPC(info.FullMethod)
+	// a panicking handler is counted as codes.Internal, which RecoveryInterceptor returns
+	code := codes.Internal
+	defer func() { done(code) }()
+
+	resp, err := handler(ctx, req)
+	code = status.Code(err)
+
+	return resp, err
+}
+
+// MetricsStreamInterceptor feeds metrickit with the count, latency and status code of streams.
+func MetricsStreamInterceptor(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	done := metrickit.StartGRPC(info.FullMethod)
+	code := codes.Internal
+	defer func() { done(code) }()
+
+	err := handler(srv, ss)
+	code = status.Code(err)
+
+	return err
+}
+
+type routeKey struct{}
+
+// route is filled by the handler matching the request, so the middleware observes the route pattern.
+type route struct {
+	pattern string
+}
+
+// setRoute records the route pattern of the request for metricsMiddleware.
+func setRoute(ctx context.Context, pattern string) {
+	if r, ok := ctx.Value(routeKey{}).(*route); ok {
+		r.pattern = pattern
+	}
+}
+
+// withRouteHolder returns the request carrying the route filled by the handler, which is shared by middlewares.
+// The pattern is "unmatched" until the request is routed.
+func withRouteHolder(r *http.Request) (*http.Request, *route) {
+	if rt, ok := r.Context().Value(routeKey{}).(*route); ok {
+		return r, rt
+	}
+
+	rt := &route{pattern: "unmatched"}
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, rt)), rt
+}
+
+// withRoute records the pattern of the handler registered in http.ServeMux.
+func withRoute(pattern string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setRoute(r.Context(), pattern)
+		h(w, r)
+	}
+}
+
+// metricsMiddleware feeds metrickit with the count, latency and status code of gateway requests by route pattern.
+// Requests matching no route are labeled "unmatched" to bound the cardinality.
+func metricsMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		done := metrickit.StartHTTP(r.Method)
+		r, rt := withRouteHolder(r)
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+
+		h.ServeHTTP(sw, r)
+		done(rt.pattern, sw.code)
+	})
+}
+
+// statusWriter records the status code written into the response.
+type statusWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.code = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming responses of the gateway working.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
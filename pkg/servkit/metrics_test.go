@@ -0,0 +1,100 @@
+package servkit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+
+	"demo/pkg/metrickit"
+)
+
+type metricsSuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (s *metricsSuite) SetupSuite() {
+	dir, err := os.MkdirTemp("", "metrics")
+	s.Require().NoError(err)
+	s.dir = dir
+}
+
+func (s *metricsSuite) TearDownSuite() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *metricsSuite) SetupTest()    {}
+func (s *metricsSuite) TearDownTest() {}
+
+func TestMetricsSuite(t *testing.T) {
+	suite.Run(t, new(metricsSuite))
+}
+
+func (s *metricsSuite) TestMetrics() {
+	cli, stop := serveExample(&s.Suite, s.dir)
+	defer stop()
+
+	for _, path := range []string{"/v1/login", "/unknown"} {
+		resp, err := cli.Post("http://localhost"+path, "application/json",
+			strings.NewReader(`{"Username":"user","Password":"pass"}`))
+		s.Require().NoError(err, path)
+		resp.Body.Close()
+	}
+	// arbitrary methods don't blow up the cardinality
+	req, err := http.NewRequest("FOOBAR", "http://localhost/v1/login", nil)
+	s.Require().NoError(err)
+	resp, err := cli.Do(req)
+	s.Require().NoError(err)
+	resp.Body.Close()
+
+	resp, err = cli.Get("http://localhost/metrics")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	b, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+
+	for _, e := range []string{
+		`grpc_server_handled_total{grpc_code="OK",grpc_method="/example.Example/Login"}`,
+		`http_requests_total{code="200",method="POST",route="/v1/login"}`,
+		`http_requests_total{code="404",method="POST",route="unmatched"}`,
+		`method="other"`,
+		// the request scraping metrics is in flight
+		`http_requests_in_flight 1`,
+	} {
+		s.Require().Contains(string(b), e)
+	}
+	s.Require().NotContains(string(b), "FOOBAR")
+}
+
+func (s *metricsSuite) TestPanic() {
+	unary := &grpc.UnaryServerInfo{FullMethod: "/example.Example/Panic"}
+	s.Require().Panics(func() {
+		_, _ = MetricsInterceptor(context.Background(), nil, unary,
+			func(context.Context, interface{}) (interface{}, error) { panic("boom") })
+	})
+	stream := &grpc.StreamServerInfo{FullMethod: "/example.Example/PanicStream"}
+	s.Require().Panics(func() {
+		_ = MetricsStreamInterceptor(nil, mockServerStream{}, stream,
+			func(interface{}, grpc.ServerStream) error { panic("boom") })
+	})
+
+	rec := httptest.NewRecorder()
+	metrickit.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, e := range []string{
+		`grpc_server_handled_total{grpc_code="Internal",grpc_method="/example.Example/Panic"} 1`,
+		`grpc_server_handled_total{grpc_code="Internal",grpc_method="/example.Example/PanicStream"} 1`,
+		`grpc_server_in_flight{grpc_method="/example.Example/Panic"} 0`,
+		`grpc_server_in_flight{grpc_method="/example.Example/PanicStream"} 0`,
+	} {
+		s.Require().Contains(rec.Body.String(), e)
+	}
+}
@@ -0,0 +1,164 @@
+package servkit
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+
+	"demo/pkg/bootkit"
+	"demo/pkg/logger"
+)
+
+// RunMuxServer serves gRPC and the gateway on a single listener at addr.
+// Requests over HTTP/2 with the content type `application/grpc` go to the gRPC server, others go to the gateway.
+// It's HTTP/2 cleartext (h2c) by default, or TLS with WithTLS(). The gateway dials the server itself,
+// so WithDialTLS() is required along with WithTLS().
+func RunMuxServer(
+	ctx context.Context, addr string,
+	shutdown bootkit.ShutdownFunc,
+	registerServers RegisterServerFunc,
+	registerHandlers RegisterHandlerFunc,
+	options ...ServOptions,
+) error {
+	o := applyServOptions(options...)
+
+	l, err := newServCertLoader(ctx, o, "mux-server-tls")
+	if err != nil {
+		return err
+	}
+	if l != nil && o.dialTLS == nil {
+		return fmt.Errorf("%w: dialing the server itself over TLS requires WithDialTLS()", ErrInvalidTLSConfig)
+	}
+
+	creds, err := dialCredentials(ctx, o)
+	if err != nil {
+		return err
+	}
+
+	lis, err := listen(addr)
+	if err != nil {
+		logger.Ctx(ctx).Error("listen failed",
+			logger.WithError(err),
+			logger.WithField("addr", addr),
+		)
+		return err
+	}
+
+	dr := newDrainer()
+	serv, hc := newGrpcServer(o, dr, registerServers)
+	serving := addServingProbe(o)
+
+	// the connection is kept instead of going idle, otherwise the gateway probe fails while there is no traffic.
+	// requests of the gateway are marked, so they're served while draining along with their HTTP requests.
+	o.grpcDialOpts = append(o.grpcDialOpts, grpc.WithTransportCredentials(creds), grpc.WithIdleTimeout(0),
+		grpc.WithChainUnaryInterceptor(injectTraceUnary, dr.injectTokenUnary),
+		grpc.WithChainStreamInterceptor(injectTraceStream, dr.injectTokenStream),
+	)
+	conn, err := grpc.NewClient(dialTarget(addr, lis), o.grpcDialOpts...)
+	if err != nil {
+		logger.Ctx(ctx).Error("grpc.NewClient failed", logger.WithError(err), logger.WithField("addr", addr))
+		_ = lis.Close()
+		return err
+	}
+
+	gw, err := newGatewayHandler(ctx, o, dr, conn, registerHandlers)
+	if err != nil {
+		_ = lis.Close()
+		_ = conn.Close()
+		return err
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isGrpcRequest(r) {
+			serv.ServeHTTP(w, r)
+			return
+		}
+		gw.ServeHTTP(w, r)
+	})
+
+	s := &http.Server{
+		Addr:              addr,
+		ReadHeaderTimeout: o.readHeaderTimeout,
+		WriteTimeout:      o.writeTimeout,
+		IdleTimeout:       o.idleTimeout,
+	}
+	if l != nil {
+		s.Handler = handler
+		s.TLSConfig = l.serverConfig("h2", "http/1.1")
+		if err := http2.ConfigureServer(s, &http2.Server{}); err != nil {
+			logger.Ctx(ctx).Error("http2.ConfigureServer failed", logger.WithError(err))
+			_ = lis.Close()
+			_ = conn.Close()
+			return err
+		}
+		lis = tls.NewListener(lis, s.TLSConfig)
+	} else {
+		s.Handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: o.idleTimeout})
+	}
+
+	shutdown(func() error {
+		serving.Store(false)
+		hc.shutdown()
+		logger.Info("Shutting down the gRPC and gateway server ...")
+		// requests still in flight are cut off once the drain timeout passes
+		err := dr.stop("mux-server", o.drainTimeout, func() error {
+			if err := s.Shutdown(context.Background()); err != nil {
+				return err
+			}
+			// drain gRPC streams over hijacked h2c connections
+			serv.GracefulStop()
+			return nil
+		}, func() {
+			_ = s.Close()
+			serv.Stop()
+		})
+		if err != nil {
+			return err
+		}
+
+		// close connection
+		return conn.Close()
+	}, bootkit.WithShutdownName("mux-server"))
+
+	logger.Ctx(ctx).Info(fmt.Sprintf("Starting gRPC and gateway server listening at %s", addr))
+
+	serving.Store(true)
+	go hc.run()
+	conn.Connect()
+	o.ready()
+	if err := s.Serve(lis); err != http.ErrServerClosed {
+		logger.Ctx(ctx).Error("Failed to listen and serve", logger.WithError(err))
+		return err
+	}
+
+	return nil
+}
+
+// isGrpcRequest checks whether the request is sent by a gRPC client.
+func isGrpcRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// dialTarget returns the target dialing the listener, an unspecified host like ":8000" is dialed via localhost.
+func dialTarget(addr string, lis net.Listener) string {
+	if strings.HasPrefix(addr, unixScheme) {
+		return addr
+	}
+
+	host, port, err := net.SplitHostPort(lis.Addr().String())
+	if err != nil {
+		return addr
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	return net.JoinHostPort(host, port)
+}
@@ -0,0 +1,109 @@
+package servkit
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"demo/pkg/bootkit"
+)
+
+func (s *tlsSuite) TestMuxServer() {
+	servCert, servKey := s.issue(2, "server", s.ca, s.caKey)
+	servFile := s.write("server.pem", servCert, servKey)
+
+	tests := []struct {
+		Desc      string
+		Options   []ServOptions
+		GrpcCreds credentials.TransportCredentials
+		TLSConfig *tls.Config
+	}{
+		{
+			Desc:      "h2c",
+			GrpcCreds: insecure.NewCredentials(),
+		},
+		{
+			Desc: "tls",
+			Options: []ServOptions{
+				WithTLS(servFile, servFile+".key"),
+				WithDialTLS(s.caFile, "localhost"),
+			},
+			GrpcCreds: credentials.NewTLS(&tls.Config{RootCAs: s.certPool(), ServerName: "localhost"}),
+			TLSConfig: &tls.Config{RootCAs: s.certPool(), ServerName: "localhost"},
+		},
+	}
+
+	for _, t := range tests {
+		sock := filepath.Join(s.dir, "mux.sock")
+		addr := "unix://" + sock
+		app := bootkit.New()
+		app.RegisterServer("mux-server", func(shutdown bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
+			return RunMuxServer(context.Background(), addr, shutdown,
+				func(serv *grpc.Server) {
+					healthpb.RegisterHealthServer(serv, health.NewServer())
+				},
+				func(context.Context, *gwruntime.ServeMux, *grpc.ClientConn) error { return nil },
+				append(t.Options, WithBootApp(app), WithReady(ready))...,
+			)
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s.Require().NoError(app.Run(ctx), t.Desc)
+
+		// native gRPC
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(t.GrpcCreds))
+		s.Require().NoError(err, t.Desc)
+		rctx, rcancel := context.WithTimeout(context.Background(), time.Second)
+		resp, err := healthpb.NewHealthClient(conn).Check(rctx, &healthpb.HealthCheckRequest{})
+		rcancel()
+		s.Require().NoError(err, t.Desc)
+		s.Require().Equal(healthpb.HealthCheckResponse_SERVING, resp.GetStatus(), t.Desc)
+		s.Require().NoError(conn.Close(), t.Desc)
+
+		// REST over HTTP/1.1
+		cli := &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+				},
+				TLSClientConfig: t.TLSConfig,
+			},
+		}
+		scheme := "http"
+		if t.TLSConfig != nil {
+			scheme = "https"
+		}
+		s.Require().Eventually(func() bool {
+			resp, err := cli.Get(scheme + "://localhost/readyz")
+			if err != nil {
+				return false
+			}
+			defer resp.Body.Close()
+			return resp.StatusCode == http.StatusOK
+		}, time.Second, 10*time.Millisecond, t.Desc)
+
+		cancel()
+		app.Wait()
+	}
+}
+
+func (s *tlsSuite) TestMuxServerWithoutDialTLS() {
+	servCert, servKey := s.issue(2, "server", s.ca, s.caKey)
+	servFile := s.write("server.pem", servCert, servKey)
+
+	err := RunMuxServer(context.Background(), "unix://"+filepath.Join(s.dir, "mux.sock"), nil, nil, nil,
+		WithBootApp(bootkit.New()),
+		WithTLS(servFile, servFile+".key"),
+	)
+	s.Require().ErrorIs(err, ErrInvalidTLSConfig)
+}
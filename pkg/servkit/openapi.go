@@ -0,0 +1,253 @@
+package servkit
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"demo/pkg/envkit"
+	"demo/pkg/logger"
+)
+
+const (
+	// openAPIPrefix is where the specifications are served.
+	openAPIPrefix = "/openapiv2/"
+	// mergedSpec is the name of the document merging all specifications.
+	mergedSpec = "apidocs.swagger.json"
+	// docsAssetsPrefix is where the assets of Swagger UI are served.
+	docsAssetsPrefix = "/docs/assets/"
+)
+
+var (
+	//go:embed static/docs.html
+	docsHTML string
+
+	docsTemplate = template.Must(template.New("docs").Parse(docsHTML))
+
+	// swaggerUI is the pinned distribution of Swagger UI, see SWAGGER_UI_VERSION in Makefile.
+	//go:embed static/swagger-ui
+	swaggerUI embed.FS
+)
+
+// openAPIDocs serves the specifications generated by protoc-gen-openapiv2, their merged document,
+// and the Swagger UI listing them.
+type openAPIDocs struct {
+	fsys   fs.FS
+	merged []byte
+	docs   []byte
+}
+
+// newOpenAPIDocs loads all `*.swagger.json` files in fsys, and merges them into one document.
+func newOpenAPIDocs(fsys fs.FS) (*openAPIDocs, error) {
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(p, ".swagger.json") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([][]byte, 0, len(files))
+	for _, f := range files {
+		b, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, b)
+	}
+
+	merged, err := mergeOpenAPI(files, specs)
+	if err != nil {
+		return nil, err
+	}
+
+	type specURL struct {
+		URL  string `json:"url"`
+		Name string `json:"name"`
+	}
+	urls := []specURL{{URL: openAPIPrefix + mergedSpec, Name: "all"}}
+	for _, f := range files {
+		urls = append(urls, specURL{URL: openAPIPrefix + f, Name: strings.TrimSuffix(f, ".swagger.json")})
+	}
+
+	var docs bytes.Buffer
+	if err := docsTemplate.Execute(&docs, map[string]interface{}{"URLs": urls}); err != nil {
+		return nil, err
+	}
+
+	return &openAPIDocs{fsys: fsys, merged: merged, docs: docs.Bytes()}, nil
+}
+
+// specs returns OpenAPI specification files located under "/openapiv2/",
+// along with the merged document at "/openapiv2/apidocs.swagger.json".
+func (d *openAPIDocs) specs(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, ".swagger.json") {
+		logger.Error(fmt.Sprintf("Not Found: %s", r.URL.Path))
+		http.NotFound(w, r)
+		return
+	}
+
+	p := strings.TrimPrefix(r.URL.Path, openAPIPrefix)
+	if p == mergedSpec {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(d.merged)
+		return
+	}
+
+	http.ServeFileFS(w, r, d.fsys, path.Clean(p))
+}
+
+// ui renders the Swagger UI, whose assets are served by assets.
+func (d *openAPIDocs) ui(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(d.docs)
+}
+
+// assets returns the embedded assets of Swagger UI located under "/docs/assets/".
+func (d *openAPIDocs) assets(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, docsAssetsPrefix)
+	http.ServeFileFS(w, r, swaggerUI, path.Join("static/swagger-ui", path.Clean("/"+p)))
+}
+
+// mergeOpenAPI merges the Swagger 2.0 specifications of services into one document.
+// Paths are prefixed with the basePath of their specifications unless all of them share the same one.
+// Definitions with the same name must be identical, and an operation can't be declared twice.
+func mergeOpenAPI(names []string, specs [][]byte) ([]byte, error) {
+	type spec struct {
+		Info                map[string]interface{}            `json:"info"`
+		BasePath            string                            `json:"basePath"`
+		Tags                []map[string]interface{}          `json:"tags"`
+		Consumes            []string                          `json:"consumes"`
+		Produces            []string                          `json:"produces"`
+		Paths               map[string]map[string]interface{} `json:"paths"`
+		Definitions         map[string]interface{}            `json:"definitions"`
+		SecurityDefinitions map[string]interface{}            `json:"securityDefinitions"`
+	}
+
+	parsed := make([]spec, len(specs))
+	basePaths := map[string]struct{}{}
+	for i, b := range specs {
+		if err := json.Unmarshal(b, &parsed[i]); err != nil {
+			return nil, fmt.Errorf("%s: %w", names[i], err)
+		}
+		basePaths[parsed[i].BasePath] = struct{}{}
+	}
+
+	merged := map[string]interface{}{
+		"swagger": "2.0",
+		"info":    map[string]interface{}{"title": "API", "version": "version not set"},
+	}
+	paths := map[string]map[string]interface{}{}
+	defs := map[string]interface{}{}
+	secDefs := map[string]interface{}{}
+	tags := []map[string]interface{}{}
+	tagNames := map[interface{}]struct{}{}
+	var titles, consumes, produces []string
+
+	for i, s := range parsed {
+		if title, ok := s.Info["title"].(string); ok {
+			titles = append(titles, title)
+		}
+		if len(basePaths) == 1 && s.BasePath != "" {
+			merged["basePath"] = s.BasePath
+		}
+		consumes = appendUnique(consumes, s.Consumes...)
+		produces = appendUnique(produces, s.Produces...)
+
+		for _, t := range s.Tags {
+			if _, ok := tagNames[t["name"]]; !ok {
+				tagNames[t["name"]] = struct{}{}
+				tags = append(tags, t)
+			}
+		}
+
+		for p, ops := range s.Paths {
+			if len(basePaths) > 1 {
+				p = strings.TrimSuffix(s.BasePath, "/") + p
+			}
+			if paths[p] == nil {
+				paths[p] = map[string]interface{}{}
+			}
+			for m, op := range ops {
+				if _, ok := paths[p][m]; ok {
+					return nil, fmt.Errorf("%s: duplicate operation %s %s", names[i], strings.ToUpper(m), p)
+				}
+				paths[p][m] = op
+			}
+		}
+
+		for _, kv := range []struct {
+			dst, src map[string]interface{}
+		}{{defs, s.Definitions}, {secDefs, s.SecurityDefinitions}} {
+			for k, v := range kv.src {
+				if old, ok := kv.dst[k]; ok && !reflect.DeepEqual(old, v) {
+					return nil, fmt.Errorf("%s: conflicting definition %s", names[i], k)
+				}
+				kv.dst[k] = v
+			}
+		}
+	}
+
+	if len(titles) > 0 {
+		sort.Strings(titles)
+		merged["info"].(map[string]interface{})["title"] = strings.Join(titles, ", ")
+	}
+	merged["tags"] = tags
+	if len(consumes) > 0 {
+		merged["consumes"] = consumes
+	}
+	if len(produces) > 0 {
+		merged["produces"] = produces
+	}
+	merged["paths"] = paths
+	merged["definitions"] = defs
+	if len(secDefs) > 0 {
+		merged["securityDefinitions"] = secDefs
+	}
+
+	return json.MarshalIndent(merged, "", "  ")
+}
+
+func appendUnique(ss []string, vals ...string) []string {
+	for _, v := range vals {
+		found := false
+		for _, s := range ss {
+			found = found || s == v
+		}
+		if !found {
+			ss = append(ss, v)
+		}
+	}
+
+	return ss
+}
+
+// enabledIn reports whether a feature is enabled in the current namespace, it's enabled in the given
+// namespaces, or in all namespaces except envkit.EnvProduction if none is given.
+func enabledIn(envs []envkit.Env) bool {
+	ns := envkit.Namespace()
+	if len(envs) == 0 {
+		return ns != envkit.EnvProduction
+	}
+
+	for _, env := range envs {
+		if env == ns {
+			return true
+		}
+	}
+	return false
+}
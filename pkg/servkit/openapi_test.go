@@ -0,0 +1,188 @@
+package servkit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	apiDocs "demo/proto"
+)
+
+type openAPISuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (s *openAPISuite) SetupSuite() {
+	dir, err := os.MkdirTemp("", "openapi")
+	s.Require().NoError(err)
+	s.dir = dir
+}
+
+func (s *openAPISuite) TearDownSuite() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *openAPISuite) SetupTest()    {}
+func (s *openAPISuite) TearDownTest() {}
+
+func TestOpenAPISuite(t *testing.T) {
+	suite.Run(t, new(openAPISuite))
+}
+
+func (s *openAPISuite) TestMerge() {
+	const status = `"rpcStatus":{"type":"object"}`
+	tests := []struct {
+		Desc        string
+		Specs       []string
+		ExpPaths    []string
+		ExpBasePath string
+		ExpErr      bool
+	}{
+		{
+			Desc: "same base path",
+			Specs: []string{
+				`{"basePath":"/","paths":{"/v1/login":{"post":{}}},"definitions":{` + status + `}}`,
+				`{"basePath":"/","paths":{"/v1/login":{"get":{}},"/v1/order":{"post":{}}},"definitions":{` + status + `}}`,
+			},
+			ExpPaths:    []string{"/v1/login", "/v1/order"},
+			ExpBasePath: "/",
+		},
+		{
+			Desc: "prefixed by base paths",
+			Specs: []string{
+				`{"basePath":"/example","paths":{"/v1/login":{"post":{}}}}`,
+				`{"paths":{"/v1/order":{"post":{}}}}`,
+			},
+			ExpPaths: []string{"/example/v1/login", "/v1/order"},
+		},
+		{
+			Desc: "duplicate operation",
+			Specs: []string{
+				`{"paths":{"/v1/login":{"post":{}}}}`,
+				`{"paths":{"/v1/login":{"post":{}}}}`,
+			},
+			ExpErr: true,
+		},
+		{
+			Desc: "conflicting definition",
+			Specs: []string{
+				`{"definitions":{` + status + `}}`,
+				`{"definitions":{"rpcStatus":{"type":"string"}}}`,
+			},
+			ExpErr: true,
+		},
+	}
+
+	for _, t := range tests {
+		names := make([]string, len(t.Specs))
+		specs := make([][]byte, len(t.Specs))
+		for i, spec := range t.Specs {
+			names[i] = "spec.swagger.json"
+			specs[i] = []byte(spec)
+		}
+
+		b, err := mergeOpenAPI(names, specs)
+		if t.ExpErr {
+			s.Require().Error(err, t.Desc)
+			continue
+		}
+		s.Require().NoError(err, t.Desc)
+
+		var merged struct {
+			BasePath string                     `json:"basePath"`
+			Paths    map[string]json.RawMessage `json:"paths"`
+		}
+		s.Require().NoError(json.Unmarshal(b, &merged), t.Desc)
+		s.Require().Equal(t.ExpBasePath, merged.BasePath, t.Desc)
+		s.Require().Len(merged.Paths, len(t.ExpPaths), t.Desc)
+		for _, p := range t.ExpPaths {
+			s.Require().Contains(merged.Paths, p, t.Desc)
+		}
+	}
+}
+
+func (s *openAPISuite) TestGateway() {
+	tests := []struct {
+		Desc      string
+		Namespace string
+		Path      string
+		ExpCode   int
+		ExpBody   string
+	}{
+		{
+			Desc:      "spec",
+			Namespace: "development",
+			Path:      "/openapiv2/example/example.swagger.json",
+			ExpCode:   http.StatusOK,
+			ExpBody:   `"operationId": "Example_Login"`,
+		},
+		{
+			Desc:      "merged spec",
+			Namespace: "development",
+			Path:      "/openapiv2/apidocs.swagger.json",
+			ExpCode:   http.StatusOK,
+			ExpBody:   `"operationId": "Example_Login"`,
+		},
+		{
+			Desc:      "swagger ui",
+			Namespace: "development",
+			Path:      "/docs",
+			ExpCode:   http.StatusOK,
+			ExpBody:   `"url":"/openapiv2/example/example.swagger.json"`,
+		},
+		{
+			Desc:      "swagger ui with embedded assets",
+			Namespace: "development",
+			Path:      "/docs",
+			ExpCode:   http.StatusOK,
+			ExpBody:   `<script src="/docs/assets/swagger-ui-bundle.js"></script>`,
+		},
+		{
+			Desc:      "embedded asset",
+			Namespace: "development",
+			Path:      "/docs/assets/swagger-ui.css",
+			ExpCode:   http.StatusOK,
+			ExpBody:   ".swagger-ui",
+		},
+		{
+			Desc:      "missing asset",
+			Namespace: "development",
+			Path:      "/docs/assets/missing.js",
+			ExpCode:   http.StatusNotFound,
+		},
+		{
+			Desc:      "not a spec",
+			Namespace: "development",
+			Path:      "/openapiv2/../../go.mod",
+			ExpCode:   http.StatusNotFound,
+		},
+		{
+			Desc:      "disabled in production",
+			Namespace: "production",
+			Path:      "/docs",
+			ExpCode:   http.StatusNotFound,
+		},
+	}
+
+	for _, t := range tests {
+		s.T().Setenv("ENV_NAMESPACE", t.Namespace)
+
+		cli, stop := serveExample(&s.Suite, s.dir, WithOpenAPI(apiDocs.OpenAPI))
+		resp, err := cli.Get("http://localhost" + t.Path)
+		s.Require().NoError(err, t.Desc)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		stop()
+
+		s.Require().NoError(err, t.Desc)
+		s.Require().Equal(t.ExpCode, resp.StatusCode, t.Desc)
+		s.Require().Contains(string(body), t.ExpBody, t.Desc)
+		s.Require().NotContains(string(body), "unpkg.com", t.Desc)
+	}
+}
@@ -1,10 +1,21 @@
 package servkit
 
 import (
+	"io/fs"
+	"net/http"
+	"time"
+
 	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 
+	"demo/pkg/bootkit"
+	"demo/pkg/envkit"
+	"demo/pkg/errorKit"
 	"demo/pkg/httpkit"
+	"demo/pkg/idempotkit"
+	"demo/pkg/ratekit"
+	"demo/pkg/telemetrykit"
 )
 
 // ServOptions sets server options.
@@ -17,6 +28,44 @@ type servOptions struct {
 	gwServMuxOpts []gwruntime.ServeMuxOption
 	grpcDialOpts  []grpc.DialOption
 	grpcServOpts  []grpc.ServerOption
+	app           *bootkit.App
+	ready         bootkit.ReadyFunc
+
+	inProcess *InProcess
+
+	servTLS        *tlsFiles
+	dialTLS        *tlsFiles
+	dialServerName string
+
+	healthInterval time.Duration
+	healthDeps     map[string][]string
+	reflectionEnvs []envkit.Env
+
+	openAPI     fs.FS
+	openAPIEnvs []envkit.Env
+
+	lifecycleEnvs []envkit.Env
+
+	rateLimits []rateLimitRule
+
+	deadlines       map[string]deadline
+	defaultDeadline deadline
+
+	telemetry telemetrykit.Provider
+
+	idempotency        map[string]idempotencyRule
+	defaultIdempotency *idempotencyRule
+
+	compressMinSize int
+
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	drainTimeout      time.Duration
+}
+
+type tlsFiles struct {
+	certFile, keyFile, caFile string
 }
 
 // EmptyServOption does not alter the server configuration. It can be embedded
@@ -74,8 +123,306 @@ func WithGrpcServOptions(options ...grpc.ServerOption) ServOptions {
 	})
 }
 
+// WithBootApp specifies the bootkit.App which the server registers probes into.
+// It's bootkit.Default() by default.
+func WithBootApp(app *bootkit.App) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.app = app
+	})
+}
+
+// WithReady specifies the function called once the server is listening,
+// which is usually the bootkit.ReadyFunc passed into bootkit.RegisterServer().
+func WithReady(ready bootkit.ReadyFunc) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.ready = ready
+	})
+}
+
+// WithInProcess connects the gateway to the gRPC server in the same process, without a network hop. i.e.,
+// inProcess := servkit.NewInProcess()
+// servkit.RunGrpcServer(ctx, grpcAddr, shutdown, registerServers, servkit.WithInProcess(inProcess))
+// servkit.RunGrpcGateway(ctx, gwAddr, "", shutdown, registerHandlers, servkit.WithInProcess(inProcess))
+// The gRPC server keeps listening at its address for other clients, and grpcAddr of the gateway is ignored.
+// Along with WithTLS(), the gateway verifies the server by the name given in WithDialTLS().
+func WithInProcess(p *InProcess) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.inProcess = p
+	})
+}
+
+// WithTLS serves gRPC over TLS with the certificate and key files,
+// which are reloaded on SIGHUP without restarting the server.
+func WithTLS(certFile, keyFile string) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		if opts.servTLS == nil {
+			opts.servTLS = &tlsFiles{}
+		}
+		opts.servTLS.certFile = certFile
+		opts.servTLS.keyFile = keyFile
+	})
+}
+
+// WithClientCA enables mTLS along with WithTLS(), the server requires client certificates signed by the CA.
+// The CA file is reloaded on SIGHUP as well.
+func WithClientCA(caFile string) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		if opts.servTLS == nil {
+			opts.servTLS = &tlsFiles{}
+		}
+		opts.servTLS.caFile = caFile
+	})
+}
+
+// WithDialTLS dials the gRPC server over TLS, verifying it with the CA file, or the system roots if caFile is empty.
+// serverName overrides the name verified in the server certificate, it's the host of the address by default.
+func WithDialTLS(caFile, serverName string) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		if opts.dialTLS == nil {
+			opts.dialTLS = &tlsFiles{}
+		}
+		opts.dialTLS.caFile = caFile
+		opts.dialServerName = serverName
+	})
+}
+
+// WithDialClientCert presents the client certificate when dialing the gRPC server with mTLS.
+// The certificate and key files are reloaded on SIGHUP.
+func WithDialClientCert(certFile, keyFile string) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		if opts.dialTLS == nil {
+			opts.dialTLS = &tlsFiles{}
+		}
+		opts.dialTLS.certFile = certFile
+		opts.dialTLS.keyFile = keyFile
+	})
+}
+
+// WithHealth registers the standard health service grpc.health.v1.Health. Every registered service, and the
+// overall server named "", is SERVING while readiness probes of bootkit pass, which are checked every interval.
+// All of them become NOT_SERVING once shutdown starts.
+func WithHealth(interval time.Duration) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		if interval <= 0 {
+			interval = defaultHealthInterval
+		}
+		opts.healthInterval = interval
+	})
+}
+
+// WithHealthDependencies makes the serving status of the service depend on the named readiness probes only,
+// ex: WithHealthDependencies("example.Example", "mysql"). It takes effect along with WithHealth().
+func WithHealthDependencies(service string, probes ...string) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		if opts.healthDeps == nil {
+			opts.healthDeps = map[string][]string{}
+		}
+		opts.healthDeps[service] = append([]string{}, probes...)
+	})
+}
+
+// WithReflection registers server reflection in the given namespaces of envkit.Namespace(),
+// or in all namespaces except envkit.EnvProduction if none is given.
+func WithReflection(envs ...envkit.Env) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.reflectionEnvs = append([]envkit.Env{}, envs...)
+	})
+}
+
+// WithOpenAPI serves the `*.swagger.json` files in fsys under "/openapiv2/", their merged document at
+// "/openapiv2/apidocs.swagger.json", and the Swagger UI at "/docs" in the gateway. It's enabled in the given
+// namespaces of envkit.Namespace(), or in all namespaces except envkit.EnvProduction if none is given.
+func WithOpenAPI(fsys fs.FS, envs ...envkit.Env) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.openAPI = fsys
+		opts.openAPIEnvs = append([]envkit.Env{}, envs...)
+	})
+}
+
+// WithLifecycleEndpoint serves the lifecycle state and events of the bootkit app at "/debug/lifecycle"
+// in the gateway. It's enabled in the given namespaces of envkit.Namespace(),
+// or in all namespaces except envkit.EnvProduction if none is given, which is the default.
+func WithLifecycleEndpoint(envs ...envkit.Env) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.lifecycleEnvs = append([]envkit.Env{}, envs...)
+	})
+}
+
+// WithRateLimit limits requests of the methods, or all methods if none is given, counted by the key.
+// Requests over the limit get codes.ResourceExhausted, which is HTTP 429 in the gateway, along with the
+// Retry-After and RateLimit-* headers, i.e.,
+// limiter, _ := ratekit.NewSlidingWindow(ratekit.Rate{Limit: 5, Period: time.Minute})
+// servkit.WithRateLimit(limiter, servkit.KeyByRealIP(), "/example.Example/Login")
+// It can be given multiple times, and requests are rejected if any of the limits is exceeded.
+func WithRateLimit(limiter ratekit.Limiter, key RateLimitKey, methods ...string) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		ms := map[string]struct{}{}
+		for _, m := range methods {
+			ms[m] = struct{}{}
+		}
+		opts.rateLimits = append(opts.rateLimits, rateLimitRule{limiter: limiter, key: key, methods: ms})
+	})
+}
+
+// WithDeadline bounds how long the methods, or all methods if none is given, can run. def applies if the client
+// doesn't set a deadline, while a deadline longer than max is capped to max, zero means unbounded.
+// Deadlines given with methods take precedence over the `(deadline.deadline)` method option in proto files,
+// which takes precedence over the ones given without methods.
+func WithDeadline(def, max time.Duration, methods ...string) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		if len(methods) == 0 {
+			opts.defaultDeadline = deadline{def: def, max: max}
+			return
+		}
+
+		if opts.deadlines == nil {
+			opts.deadlines = map[string]deadline{}
+		}
+		for _, m := range methods {
+			opts.deadlines[m] = deadline{def: def, max: max}
+		}
+	})
+}
+
+// WithReadHeaderTimeout specifies http.Server.ReadHeaderTimeout of the gateway, it's 10s by default.
+func WithReadHeaderTimeout(timeout time.Duration) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.readHeaderTimeout = timeout
+	})
+}
+
+// WithWriteTimeout specifies http.Server.WriteTimeout of the gateway, it's unbounded by default.
+// It caps gRPC streams as well in RunMuxServer(), so prefer WithDeadline() there.
+func WithWriteTimeout(timeout time.Duration) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.writeTimeout = timeout
+	})
+}
+
+// WithIdleTimeout specifies http.Server.IdleTimeout of the gateway, it's 2m by default.
+func WithIdleTimeout(timeout time.Duration) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.idleTimeout = timeout
+	})
+}
+
+// WithDrainTimeout specifies how long the server drains in-flight requests on shutdown, it's 20s by default.
+// New requests get codes.Unavailable, or HTTP 503 in the gateway, while draining. Once the timeout passes,
+// the server stops hard and the requests still in flight are cut off. Zero means waiting until they finish.
+func WithDrainTimeout(timeout time.Duration) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.drainTimeout = timeout
+	})
+}
+
+// WithTelemetry specifies the provider tracing requests, it's telemetrykit.Default() by default.
+func WithTelemetry(provider telemetrykit.Provider) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.telemetry = provider
+	})
+}
+
+// WithIdempotency replays the first response of unary requests to the methods, or all methods if none is given,
+// with the same Idempotency-Key header, which is kept in the store for ttl. Duplicates get codes.Aborted,
+// which is HTTP 409 in the gateway, while the first request is in flight. Keys of requests failed transiently,
+// ex: codes.Unavailable, are released for retries. Keys are scoped by the authorization header,
+// or by the payload for requests without it, ex: Login. i.e.,
+// servkit.WithIdempotency(idempotkit.NewMySQLStore(sqlDB), 24*time.Hour, "/example.Example/CreateOrder")
+// The store given with methods takes precedence over the one without methods.
+func WithIdempotency(store idempotkit.Store, ttl time.Duration, methods ...string) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		r := idempotencyRule{store: store, ttl: ttl}
+		if len(methods) == 0 {
+			opts.defaultIdempotency = &r
+			return
+		}
+
+		if opts.idempotency == nil {
+			opts.idempotency = map[string]idempotencyRule{}
+		}
+		for _, m := range methods {
+			opts.idempotency[m] = r
+		}
+	})
+}
+
+// WithCompressMinSize specifies the size from which responses of the gateway are compressed, it's 1KiB by default.
+// Responses are compressed with zstd, br, gzip or deflate negotiated by the Accept-Encoding header,
+// and streaming responses are compressed regardless of the size. A negative size disables compression.
+func WithCompressMinSize(size int) ServOptions {
+	return newFuncServOption(func(opts *servOptions) {
+		opts.compressMinSize = size
+	})
+}
+
 func applyServOptions(options ...ServOptions) *servOptions {
-	opts := &servOptions{}
+	opts := &servOptions{
+		app:       bootkit.Default(),
+		ready:     func() {},
+		telemetry: telemetrykit.Default(),
+
+		readHeaderTimeout: defaultReadHeaderTimeout,
+		idleTimeout:       defaultIdleTimeout,
+		drainTimeout:      defaultDrainTimeout,
+		compressMinSize:   defaultCompressMinSize,
+	}
+	for _, o := range options {
+		o.apply(opts)
+	}
+
+	return opts
+}
+
+// ErrorOptions sets options of the error envelope rendered by RegisterHTTPErrorHandler().
+type ErrorOptions interface {
+	apply(*errorOptions)
+}
+
+type errorOptions struct {
+	status       string
+	httpStatuses map[codes.Code]int
+}
+
+// funcErrorOption wraps a function that modifies errorOptions into an
+// implementation of the ErrorOptions interface.
+type funcErrorOption struct {
+	f func(*errorOptions)
+}
+
+func (o *funcErrorOption) apply(do *errorOptions) {
+	o.f(do)
+}
+
+func newFuncErrorOption(f func(*errorOptions)) *funcErrorOption {
+	return &funcErrorOption{
+		f: f,
+	}
+}
+
+// WithErrorStatus specifies the status string of the error envelope, it's "error" by default.
+func WithErrorStatus(s string) ErrorOptions {
+	return newFuncErrorOption(func(opts *errorOptions) {
+		opts.status = s
+	})
+}
+
+// WithHTTPStatus maps the business code to the HTTP status, which overrides the default mapping. i.e.,
+// servkit.WithHTTPStatus(errorKit.StatusBoIpNotAllowed, http.StatusUnauthorized)
+func WithHTTPStatus(code codes.Code, httpStatus int) ErrorOptions {
+	return newFuncErrorOption(func(opts *errorOptions) {
+		opts.httpStatuses[code] = httpStatus
+	})
+}
+
+func applyErrorOptions(options ...ErrorOptions) *errorOptions {
+	opts := &errorOptions{
+		status: defaultErrorStatus,
+		httpStatuses: map[codes.Code]int{
+			errorKit.StatusBoInternalError: http.StatusInternalServerError,
+			errorKit.StatusBoInvalidParams: http.StatusBadRequest,
+			errorKit.StatusBoIpNotAllowed:  http.StatusForbidden,
+		},
+	}
 	for _, o := range options {
 		o.apply(opts)
 	}
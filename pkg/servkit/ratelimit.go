@@ -0,0 +1,226 @@
+package servkit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"demo/pkg/logger"
+	"demo/pkg/ratekit"
+)
+
+// headers of rate limits, which are forwarded as they are by the gateway.
+const (
+	RateLimitLimit     = "ratelimit-limit"
+	RateLimitRemaining = "ratelimit-remaining"
+	RateLimitReset     = "ratelimit-reset"
+	RetryAfter         = "retry-after"
+)
+
+var rateLimitHeaders = []string{RateLimitLimit, RateLimitRemaining, RateLimitReset, RetryAfter}
+
+// RateLimitKey returns the key the request is counted by, the request isn't limited if the key is empty.
+type RateLimitKey func(ctx context.Context, method string) string
+
+// KeyByMethod counts requests by the full gRPC method, ex: "/example.Example/Login".
+func KeyByMethod() RateLimitKey {
+	return func(_ context.Context, method string) string {
+		return method
+	}
+}
+
+// KeyByRealIP counts requests by the client IP forwarded by the gateway,
+// or the peer address of native gRPC requests.
+func KeyByRealIP() RateLimitKey {
+	return func(ctx context.Context, _ string) string {
+		if md, ok := GetMetadata(ctx); ok {
+			if ip := md.RealIP(); ip != "" {
+				return ip
+			}
+		}
+
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return ""
+		}
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+}
+
+// KeyByIdentity counts requests by the authorization header, i.e., the authenticated identity.
+// The value is hashed so credentials aren't kept in the store.
+func KeyByIdentity() RateLimitKey {
+	return func(ctx context.Context, _ string) string {
+		md, ok := GetMetadata(ctx)
+		if !ok {
+			return ""
+		}
+
+		v := md.Authorization()
+		if v == "" {
+			v = md.getSpecKey("authorization")
+		}
+		return hashKey(v)
+	}
+}
+
+// KeyByAPIKey counts requests by the API key in the header, which is forwarded by RegisterForwardHeaders()
+// through the gateway. The value is hashed so credentials aren't kept in the store.
+func KeyByAPIKey(header string) RateLimitKey {
+	header = strings.ToLower(header)
+	return func(ctx context.Context, _ string) string {
+		md, ok := GetMetadata(ctx)
+		if !ok {
+			return ""
+		}
+
+		v := md.Header(header)
+		if v == "" {
+			v = md.getSpecKey(header)
+		}
+		return hashKey(v)
+	}
+}
+
+// CombineKeys counts requests by all the keys, ex: CombineKeys(KeyByMethod(), KeyByRealIP()) limits each
+// client per method. The request isn't limited if any of the keys is empty.
+func CombineKeys(keys ...RateLimitKey) RateLimitKey {
+	return func(ctx context.Context, method string) string {
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			if parts[i] = k(ctx, method); parts[i] == "" {
+				return ""
+			}
+		}
+		return strings.Join(parts, "|")
+	}
+}
+
+func hashKey(v string) string {
+	if v == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(v))
+	return hex.EncodeToString(sum[:16])
+}
+
+type rateLimitRule struct {
+	limiter ratekit.Limiter
+	key     RateLimitKey
+	methods map[string]struct{}
+}
+
+type rateLimiter struct {
+	rules []rateLimitRule
+}
+
+// allow checks every rule applying to the method, and returns the result of the most restrictive one.
+// Requests are allowed if the store fails, so an outage of a shared store doesn't take the service down.
+func (l *rateLimiter) allow(ctx context.Context, method string) (ratekit.Result, bool) {
+	var ret ratekit.Result
+	limited := false
+	for i, r := range l.rules {
+		if _, ok := r.methods[method]; len(r.methods) > 0 && !ok {
+			continue
+		}
+		key := r.key(ctx, method)
+		if key == "" {
+			continue
+		}
+
+		res, err := r.limiter.Allow(ctx, strconv.Itoa(i)+":"+key)
+		if err != nil {
+			logger.Ctx(ctx).Error("limiter.Allow failed",
+				logger.WithError(err),
+				logger.WithField("grpc.method", method),
+			)
+			continue
+		}
+
+		if !limited || restricts(res, ret) {
+			ret = res
+		}
+		limited = true
+	}
+
+	return ret, limited
+}
+
+// restricts reports whether the result a is more restrictive than b.
+func restricts(a, b ratekit.Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+
+	return a.Remaining < b.Remaining
+}
+
+func (l *rateLimiter) unary(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ret, limited := l.allow(ctx, info.FullMethod)
+	if limited {
+		if err := grpc.SetHeader(ctx, rateLimitMD(ret)); err != nil {
+			logger.Ctx(ctx).Error("grpc.SetHeader failed", logger.WithError(err))
+		}
+		if !ret.Allowed {
+			return nil, errRateLimited
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+func (l *rateLimiter) stream(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	ret, limited := l.allow(ss.Context(), info.FullMethod)
+	if limited {
+		if err := ss.SetHeader(rateLimitMD(ret)); err != nil {
+			logger.Ctx(ss.Context()).Error("ServerStream.SetHeader failed", logger.WithError(err))
+		}
+		if !ret.Allowed {
+			return errRateLimited
+		}
+	}
+
+	return handler(srv, ss)
+}
+
+var errRateLimited = status.Error(codes.ResourceExhausted, "rate limit exceeded")
+
+// rateLimitMD renders the result as RateLimit headers, durations are in seconds rounded up.
+func rateLimitMD(ret ratekit.Result) metadata.MD {
+	md := metadata.Pairs(
+		RateLimitLimit, strconv.Itoa(ret.Limit),
+		RateLimitRemaining, strconv.Itoa(ret.Remaining),
+		RateLimitReset, seconds(ret.ResetAfter),
+	)
+	if !ret.Allowed {
+		md.Set(RetryAfter, seconds(ret.RetryAfter))
+	}
+
+	return md
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,156 @@
+package servkit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"demo/pkg/ratekit"
+)
+
+type rateLimitSuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (s *rateLimitSuite) SetupSuite() {
+	dir, err := os.MkdirTemp("", "ratelimit")
+	s.Require().NoError(err)
+	s.dir = dir
+}
+
+func (s *rateLimitSuite) TearDownSuite() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *rateLimitSuite) SetupTest()    {}
+func (s *rateLimitSuite) TearDownTest() {}
+
+func TestRateLimitSuite(t *testing.T) {
+	suite.Run(t, new(rateLimitSuite))
+}
+
+func (s *rateLimitSuite) TestKeys() {
+	gwCtx := metadata.NewIncomingContext(context.Background(), metadata.New(map[string]string{
+		HTTPRealIP:       "10.0.0.1",
+		"authorization":  "Bearer token",
+		"spec-x-api-key": "key",
+	}))
+	grpcCtx := peer.NewContext(metadata.NewIncomingContext(context.Background(), metadata.MD{}), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 1234},
+	})
+
+	tests := []struct {
+		Desc   string
+		Ctx    context.Context
+		Key    RateLimitKey
+		ExpKey string
+	}{
+		{
+			Desc:   "method",
+			Ctx:    gwCtx,
+			Key:    KeyByMethod(),
+			ExpKey: "/example.Example/Login",
+		},
+		{
+			Desc:   "real ip from the gateway",
+			Ctx:    gwCtx,
+			Key:    KeyByRealIP(),
+			ExpKey: "10.0.0.1",
+		},
+		{
+			Desc:   "real ip from the peer",
+			Ctx:    grpcCtx,
+			Key:    KeyByRealIP(),
+			ExpKey: "10.0.0.2",
+		},
+		{
+			Desc:   "identity",
+			Ctx:    gwCtx,
+			Key:    KeyByIdentity(),
+			ExpKey: hashKey("Bearer token"),
+		},
+		{
+			Desc:   "api key",
+			Ctx:    gwCtx,
+			Key:    KeyByAPIKey("X-Api-Key"),
+			ExpKey: hashKey("key"),
+		},
+		{
+			Desc:   "combined",
+			Ctx:    gwCtx,
+			Key:    CombineKeys(KeyByMethod(), KeyByRealIP()),
+			ExpKey: "/example.Example/Login|10.0.0.1",
+		},
+		{
+			Desc:   "combined without identity",
+			Ctx:    grpcCtx,
+			Key:    CombineKeys(KeyByMethod(), KeyByIdentity()),
+			ExpKey: "",
+		},
+	}
+
+	for _, t := range tests {
+		s.Require().Equal(t.ExpKey, t.Key(t.Ctx, "/example.Example/Login"), t.Desc)
+	}
+}
+
+func (s *rateLimitSuite) TestGatewayTooManyRequests() {
+	limiter, err := ratekit.NewTokenBucket(ratekit.Rate{Limit: 2, Period: time.Hour})
+	s.Require().NoError(err)
+
+	cli, stop := serveExample(&s.Suite, s.dir,
+		WithRateLimit(limiter, KeyByRealIP(), "/example.Example/Login"),
+	)
+	defer stop()
+
+	tests := []struct {
+		Desc         string
+		ExpCode      int
+		ExpRemaining string
+		ExpRetry     string
+	}{
+		{
+			Desc:         "first",
+			ExpCode:      http.StatusOK,
+			ExpRemaining: "1",
+		},
+		{
+			Desc:         "second",
+			ExpCode:      http.StatusOK,
+			ExpRemaining: "0",
+		},
+		{
+			Desc:         "exceeded",
+			ExpCode:      http.StatusTooManyRequests,
+			ExpRemaining: "0",
+			ExpRetry:     "1800",
+		},
+	}
+
+	for _, t := range tests {
+		resp, err := cli.Post("http://localhost/v1/login", "application/json",
+			strings.NewReader(`{"Username":"user","Password":"pass"}`))
+		s.Require().NoError(err, t.Desc)
+		resp.Body.Close()
+		s.Require().Equal(t.ExpCode, resp.StatusCode, t.Desc)
+		s.Require().Equal("2", resp.Header.Get("RateLimit-Limit"), t.Desc)
+		s.Require().Equal(t.ExpRemaining, resp.Header.Get("RateLimit-Remaining"), t.Desc)
+		s.Require().Equal(t.ExpRetry, resp.Header.Get("Retry-After"), t.Desc)
+	}
+
+	// other methods aren't limited
+	resp, err := cli.Get("http://localhost/v1/item?username=user&item=item")
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Require().Empty(resp.Header.Get("RateLimit-Limit"))
+}
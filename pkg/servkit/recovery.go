@@ -0,0 +1,57 @@
+package servkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"demo/pkg/logger"
+	"demo/pkg/telemetrykit"
+)
+
+// RecoveryInterceptor turns panics in unary handlers into codes.Internal instead of crashing the process.
+func RecoveryInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverPanic(ctx, info.FullMethod, r)
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// RecoveryStreamInterceptor turns panics in stream handlers into codes.Internal instead of crashing the process.
+func RecoveryStreamInterceptor(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverPanic(ss.Context(), info.FullMethod, r)
+		}
+	}()
+
+	return handler(srv, ss)
+}
+
+// recoverPanic logs the panic with the stack, which is notified to Airbrake by the logger on error level,
+// and records it on the current span of telemetrykit.
+func recoverPanic(ctx context.Context, method string, r interface{}) error {
+	msg := fmt.Sprintf("panic recovered in %s: %v", method, r)
+
+	logger.Ctx(ctx).Error(msg, logger.WithFields(logger.Fields{
+		"grpc.method": method,
+		"stack":       string(debug.Stack()),
+	}))
+
+	telemetrykit.SpanFromContext(ctx).RecordError(errors.New(msg))
+
+	// don't expose the details of the panic to clients
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,143 @@
+package servkit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"demo/pkg/bootkit"
+	pb "demo/proto/example"
+)
+
+type recoverySuite struct {
+	suite.Suite
+}
+
+func (s *recoverySuite) SetupSuite()    {}
+func (s *recoverySuite) TearDownSuite() {}
+func (s *recoverySuite) SetupTest()     {}
+func (s *recoverySuite) TearDownTest()  {}
+
+func TestRecoverySuite(t *testing.T) {
+	suite.Run(t, new(recoverySuite))
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+}
+
+func (mockServerStream) Context() context.Context { return context.Background() }
+
+func (s *recoverySuite) TestRecoveryInterceptor() {
+	tests := []struct {
+		Desc    string
+		Handler grpc.UnaryHandler
+		ExpResp interface{}
+		ExpErr  error
+		ExpCode codes.Code
+	}{
+		{
+			Desc:    "no panic",
+			Handler: func(context.Context, interface{}) (interface{}, error) { return "ok", nil },
+			ExpResp: "ok",
+			ExpCode: codes.OK,
+		},
+		{
+			Desc: "error is passed through",
+			Handler: func(context.Context, interface{}) (interface{}, error) {
+				return nil, status.Error(codes.NotFound, "not found")
+			},
+			ExpCode: codes.NotFound,
+		},
+		{
+			Desc: "panic",
+			Handler: func(context.Context, interface{}) (interface{}, error) {
+				var m map[string]int
+				m["boom"]++
+				return nil, nil
+			},
+			ExpCode: codes.Internal,
+		},
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/example.Example/ListItems"}
+	for _, t := range tests {
+		resp, err := RecoveryInterceptor(context.Background(), nil, info, t.Handler)
+		s.Require().Equal(t.ExpResp, resp, t.Desc)
+		s.Require().Equal(t.ExpCode, status.Code(err), t.Desc)
+	}
+}
+
+func (s *recoverySuite) TestRecoveryStreamInterceptor() {
+	tests := []struct {
+		Desc    string
+		Handler grpc.StreamHandler
+		ExpCode codes.Code
+	}{
+		{
+			Desc:    "no panic",
+			Handler: func(interface{}, grpc.ServerStream) error { return nil },
+			ExpCode: codes.OK,
+		},
+		{
+			Desc:    "error is passed through",
+			Handler: func(interface{}, grpc.ServerStream) error { return errors.New("broken pipe") },
+			ExpCode: codes.Unknown,
+		},
+		{
+			Desc:    "panic",
+			Handler: func(interface{}, grpc.ServerStream) error { panic("boom") },
+			ExpCode: codes.Internal,
+		},
+	}
+
+	info := &grpc.StreamServerInfo{FullMethod: "/example.Example/WatchItems"}
+	for _, t := range tests {
+		err := RecoveryStreamInterceptor(nil, mockServerStream{}, info, t.Handler)
+		s.Require().Equal(t.ExpCode, status.Code(err), t.Desc)
+	}
+}
+
+type panicServer struct {
+	exampleServer
+}
+
+func (panicServer) Login(context.Context, *pb.LoginReq) (*pb.LoginResp, error) {
+	panic("boom")
+}
+
+func (s *recoverySuite) TestGrpcServer() {
+	// recovery runs right before the handler, so logging and metrics observe codes.Internal
+	o := applyServOptions(WithBootApp(bootkit.New()))
+	unary, stream := grpcInterceptors(o, newDrainer())
+	s.Require().Equal(reflect.ValueOf(RecoveryInterceptor).Pointer(), reflect.ValueOf(unary[len(unary)-1]).Pointer())
+	s.Require().Equal(
+		reflect.ValueOf(RecoveryStreamInterceptor).Pointer(), reflect.ValueOf(stream[len(stream)-1]).Pointer(),
+	)
+
+	serv, _ := newGrpcServer(o, newDrainer(), func(serv *grpc.Server) {
+		pb.RegisterExampleServer(serv, panicServer{})
+	})
+	lis := bufconn.Listen(inProcessBufSize)
+	go func() { _ = serv.Serve(lis) }()
+	defer serv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	_, err = pb.NewExampleClient(conn).Login(context.Background(), &pb.LoginReq{Username: "user", Password: "pass"})
+	s.Require().Equal(codes.Internal, status.Code(err))
+}
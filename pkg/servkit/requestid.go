@@ -0,0 +1,28 @@
+package servkit
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RequestID identifies the request in the gateway, it's generated if the client doesn't give one,
+// and is forwarded to the gRPC server and echoed in the response.
+const RequestID = "x-request-id"
+
+// maxRequestIDLen bounds the length of request IDs given by clients.
+const maxRequestIDLen = 128
+
+// requestIDMiddleware ensures each request has an ID, which is set in the response before handling.
+func requestIDMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestID)
+		if id == "" || len(id) > maxRequestIDLen {
+			id = uuid.NewString()
+			r.Header.Set(RequestID, id)
+		}
+		w.Header().Set(RequestID, id)
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,115 @@
+package servkit
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"demo/pkg/ctxkit"
+)
+
+type streamSuite struct {
+	suite.Suite
+}
+
+func (s *streamSuite) SetupSuite()    {}
+func (s *streamSuite) TearDownSuite() {}
+func (s *streamSuite) SetupTest()     {}
+func (s *streamSuite) TearDownTest()  {}
+
+func TestStreamSuite(t *testing.T) {
+	suite.Run(t, new(streamSuite))
+}
+
+// fakeStream receives n messages before io.EOF.
+type fakeStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+	n   int
+}
+
+func (f *fakeStream) Context() context.Context  { return f.ctx }
+func (f *fakeStream) SendMsg(interface{}) error { return nil }
+func (f *fakeStream) RecvMsg(interface{}) error {
+	if f.n == 0 {
+		return io.EOF
+	}
+	f.n--
+	return nil
+}
+
+func (s *streamSuite) TestStreamLoggingInterceptor() {
+	echo := func(_ interface{}, ss grpc.ServerStream) error {
+		for {
+			if err := ss.RecvMsg(nil); err == io.EOF {
+				return nil
+			}
+			if err := ss.SendMsg(nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	tests := []struct {
+		Desc    string
+		Handler grpc.StreamHandler
+		ExpCode codes.Code
+		ExpRecv int64
+		ExpSent int64
+	}{
+		{
+			Desc:    "echo",
+			Handler: echo,
+			ExpCode: codes.OK,
+			ExpRecv: 3,
+			ExpSent: 3,
+		},
+		{
+			Desc: "failed",
+			Handler: func(_ interface{}, ss grpc.ServerStream) error {
+				_ = ss.RecvMsg(nil)
+				return status.Error(codes.InvalidArgument, "invalid item")
+			},
+			ExpCode: codes.InvalidArgument,
+			ExpRecv: 1,
+			ExpSent: 0,
+		},
+	}
+
+	info := &grpc.StreamServerInfo{FullMethod: "/example.Example/WatchItems", IsClientStream: true, IsServerStream: true}
+	for _, t := range tests {
+		var cs *countingStream
+		err := StreamLoggingInterceptor(nil, &fakeStream{ctx: context.Background(), n: 3}, info, func(srv interface{}, ss grpc.ServerStream) error {
+			cs = ss.(*countingStream)
+			return t.Handler(srv, ss)
+		})
+		s.Require().Equal(t.ExpCode, status.Code(err), t.Desc)
+		s.Require().Equal(t.ExpRecv, cs.recv.Load(), t.Desc)
+		s.Require().Equal(t.ExpSent, cs.sent.Load(), t.Desc)
+	}
+}
+
+func (s *streamSuite) TestEnrichStreamLogger() {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.New(map[string]string{
+		HTTPMethod: "GET",
+		HTTPRoute:  "/v1/item",
+	}))
+
+	var kv ctxkit.KV
+	err := enrichStreamLogger(nil, &fakeStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(_ interface{}, ss grpc.ServerStream) error {
+		kv, _ = ctxkit.HGetAll(ss.Context(), ctxkit.KeyLogger)
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Require().Equal("GET", kv["http.method"])
+	// generated without New Relic
+	s.Require().Len(kv["nr.traceID"], 32)
+	s.Require().Len(kv["nr.spanID"], 32)
+}
@@ -0,0 +1,124 @@
+package servkit
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"demo/pkg/logger"
+	"demo/pkg/telemetrykit"
+)
+
+// tracer starts server spans of RPCs with the provider, continuing the trace propagated in the metadata.
+type tracer struct {
+	provider telemetrykit.Provider
+}
+
+func (t *tracer) unary(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, span := t.start(ctx, info.FullMethod)
+	defer span.End()
+
+	resp, err := handler(ctx, req)
+	endRPCSpan(span, err)
+
+	return resp, err
+}
+
+func (t *tracer) stream(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	ctx, span := t.start(ss.Context(), info.FullMethod)
+	defer span.End()
+
+	wss := newStreamContextWrapper(ss)
+	wss.SetContext(ctx)
+	err := handler(srv, wss)
+	endRPCSpan(span, err)
+
+	return err
+}
+
+func (t *tracer) start(ctx context.Context, method string) (context.Context, telemetrykit.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx, span := t.provider.Start(ctx, method, telemetrykit.SpanKindServer, telemetrykit.MetadataCarrier(md))
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.method", method)
+
+	return ctx, span
+}
+
+// endRPCSpan records the status code, RPCs failed by the server are marked as errors.
+func endRPCSpan(span telemetrykit.Span, err error) {
+	code := status.Code(err)
+	span.SetAttribute("rpc.grpc.status_code", int(code))
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		span.RecordError(err)
+	}
+}
+
+// injectTraceUnary propagates the trace of the gateway request to the gRPC server.
+func injectTraceUnary(
+	ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+) error {
+	return invoker(injectTrace(ctx), method, req, reply, cc, opts...)
+}
+
+// injectTraceStream propagates the trace of the gateway request to the gRPC server.
+func injectTraceStream(
+	ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+	streamer grpc.Streamer, opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	return streamer(injectTrace(ctx), desc, cc, method, opts...)
+}
+
+func injectTrace(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	telemetrykit.SpanFromContext(ctx).Inject(telemetrykit.MetadataCarrier(md))
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// tracingMiddleware starts server spans of gateway requests named after the route pattern,
+// continuing the trace propagated in the request headers. IDs of the trace are logged along with the request.
+func tracingMiddleware(provider telemetrykit.Provider) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, rt := withRouteHolder(r)
+			ctx, span := provider.Start(r.Context(), r.Method+" "+r.URL.Path, telemetrykit.SpanKindServer,
+				telemetrykit.HeaderCarrier(r.Header))
+			defer span.End()
+
+			if ws, ok := span.(telemetrykit.WebSpan); ok {
+				ws.SetWebRequest(r)
+				w = ws.SetWebResponse(w)
+			}
+			sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+
+			traceID, spanID := traceIDs(ctx)
+			ctx = logger.ContextWithFields(ctx, logger.Fields{
+				"nr.traceID": traceID,
+				"nr.spanID":  spanID,
+			})
+
+			h.ServeHTTP(sw, r.WithContext(ctx))
+
+			span.SetName(r.Method + " " + rt.pattern)
+			span.SetAttribute("http.method", r.Method)
+			span.SetAttribute("http.route", rt.pattern)
+			span.SetAttribute("http.status_code", sw.code)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package servkit
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"demo/pkg/telemetrykit"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// spanRecorder keeps exported spans after shutdown unlike tracetest.InMemoryExporter.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (r *spanRecorder) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(context.Context) error { return nil }
+
+type telemetrySuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (s *telemetrySuite) SetupSuite() {
+	dir, err := os.MkdirTemp("", "telemetry")
+	s.Require().NoError(err)
+	s.dir = dir
+}
+
+func (s *telemetrySuite) TearDownSuite() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *telemetrySuite) SetupTest()    {}
+func (s *telemetrySuite) TearDownTest() {}
+
+func TestTelemetrySuite(t *testing.T) {
+	suite.Run(t, new(telemetrySuite))
+}
+
+func (s *telemetrySuite) TestPropagation() {
+	rec := &spanRecorder{}
+	provider, err := telemetrykit.NewOTel(context.Background(), telemetrykit.WithSpanExporter(rec))
+	s.Require().NoError(err)
+
+	cli, stop := serveExample(&s.Suite, s.dir, WithTelemetry(provider))
+
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/v1/login",
+		strings.NewReader(`{"Username":"user","Password":"pass"}`))
+	s.Require().NoError(err)
+	req.Header.Set(telemetrykit.TraceParent, traceParent)
+
+	resp, err := cli.Do(req)
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	stop()
+	s.Require().NoError(provider.Shutdown(context.Background()))
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range rec.spans {
+		spans[span.Name()] = span
+	}
+
+	web, ok := spans["POST /v1/login"]
+	s.Require().True(ok)
+	s.Require().Equal("4bf92f3577b34da6a3ce929d0e0e4736", web.SpanContext().TraceID().String())
+	s.Require().Equal("00f067aa0ba902b7", web.Parent().SpanID().String())
+
+	rpc, ok := spans["/example.Example/Login"]
+	s.Require().True(ok)
+	s.Require().Equal(web.SpanContext().TraceID(), rpc.SpanContext().TraceID())
+	s.Require().Equal(web.SpanContext().SpanID(), rpc.Parent().SpanID())
+}
@@ -0,0 +1,128 @@
+package servkit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+var (
+	// ErrInvalidCA indicates no certificate can be parsed from the CA file.
+	ErrInvalidCA = errors.New("invalid CA certificate")
+)
+
+// certLoader keeps the certificate and CA loaded from files, which can be reloaded without restarting the server.
+type certLoader struct {
+	certFile, keyFile, caFile string
+
+	cert atomic.Pointer[tls.Certificate]
+	pool atomic.Pointer[x509.CertPool]
+}
+
+func newCertLoader(certFile, keyFile, caFile string) (*certLoader, error) {
+	l := &certLoader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+	}
+	if err := l.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Reload re-reads the files. The previous certificate and CA are kept if any of them is invalid.
+// It implements bootkit.ReloadFunc.
+func (l *certLoader) Reload(context.Context) error {
+	var cert *tls.Certificate
+	if l.certFile != "" || l.keyFile != "" {
+		c, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+		if err != nil {
+			return err
+		}
+		cert = &c
+	}
+
+	var pool *x509.CertPool
+	if l.caFile != "" {
+		pem, err := os.ReadFile(l.caFile)
+		if err != nil {
+			return err
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%w: %s", ErrInvalidCA, l.caFile)
+		}
+	}
+
+	l.cert.Store(cert)
+	l.pool.Store(pool)
+
+	return nil
+}
+
+// serverConfig returns the tls.Config of servers negotiating the protocols. Client certificates are required and verified
+// once the CA is provided, i.e., mTLS.
+func (l *certLoader) serverConfig(nextProtos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		// use the latest certificate and CA for each handshake
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			conf := &tls.Config{
+				MinVersion: tls.VersionTLS12,
+				NextProtos: nextProtos,
+			}
+			if cert := l.cert.Load(); cert != nil {
+				conf.Certificates = []tls.Certificate{*cert}
+			}
+			if pool := l.pool.Load(); pool != nil {
+				conf.ClientCAs = pool
+				conf.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+
+			return conf, nil
+		},
+	}
+}
+
+// clientConfig returns the tls.Config of clients verifying servers with the CA, or the system roots if it's absent.
+// The client certificate is presented if it's provided, i.e., mTLS.
+func (l *certLoader) clientConfig(serverName string) *tls.Config {
+	conf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		// RootCAs is fixed once the config is built, verify with the latest CA for each handshake instead
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("tls: server presented no certificate")
+			}
+
+			opts := x509.VerifyOptions{
+				Roots:         l.pool.Load(),
+				DNSName:       cs.ServerName,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}
+	if l.certFile != "" {
+		// use the latest certificate for each handshake
+		conf.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return l.cert.Load(), nil
+		}
+	}
+
+	return conf
+}
@@ -0,0 +1,264 @@
+package servkit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"demo/pkg/bootkit"
+)
+
+type tlsSuite struct {
+	suite.Suite
+
+	dir    string
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caFile string
+}
+
+func (s *tlsSuite) SetupSuite()    {}
+func (s *tlsSuite) TearDownSuite() {}
+func (s *tlsSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	s.ca, s.caKey = s.issue(1, "test-ca", nil, nil)
+	s.caFile = s.write("ca.pem", s.ca, nil)
+}
+func (s *tlsSuite) TearDownTest() {}
+
+func TestTLSSuite(t *testing.T) {
+	suite.Run(t, new(tlsSuite))
+}
+
+// issue creates a certificate signed by the parent, or a self-signed CA if the parent is nil.
+func (s *tlsSuite) issue(serial int64, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	s.Require().NoError(err)
+	cert, err := x509.ParseCertificate(der)
+	s.Require().NoError(err)
+
+	return cert, key
+}
+
+// write stores the certificate, and the key into name.key if it's given.
+func (s *tlsSuite) write(name string, cert *x509.Certificate, key *ecdsa.PrivateKey) string {
+	p := filepath.Join(s.dir, name)
+	s.Require().NoError(os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600))
+
+	if key != nil {
+		der, err := x509.MarshalECPrivateKey(key)
+		s.Require().NoError(err)
+		s.Require().NoError(os.WriteFile(p+".key", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600))
+	}
+
+	return p
+}
+
+func (s *tlsSuite) certPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.ca)
+
+	return pool
+}
+
+func (s *tlsSuite) TestListen() {
+	tests := []struct {
+		Desc       string
+		Addr       string
+		ExpNetwork string
+		ExpErr     error
+	}{
+		{
+			Desc:       "tcp",
+			Addr:       "127.0.0.1:0",
+			ExpNetwork: "tcp",
+		},
+		{
+			Desc:       "unix domain socket",
+			Addr:       "unix://" + filepath.Join(s.dir, "grpc.sock"),
+			ExpNetwork: "unix",
+		},
+		{
+			Desc:   "empty socket path",
+			Addr:   "unix://",
+			ExpErr: ErrInvalidAddr,
+		},
+	}
+
+	for _, t := range tests {
+		lis, err := listen(t.Addr)
+		if t.ExpErr != nil {
+			s.Require().ErrorIs(err, t.ExpErr, t.Desc)
+			continue
+		}
+		s.Require().NoError(err, t.Desc)
+		s.Require().Equal(t.ExpNetwork, lis.Addr().Network(), t.Desc)
+		s.Require().NoError(lis.Close(), t.Desc)
+	}
+}
+
+func (s *tlsSuite) TestListenStaleSocket() {
+	path := filepath.Join(s.dir, "stale.sock")
+	lis, err := net.Listen("unix", path)
+	s.Require().NoError(err)
+	// leave the socket file behind like a crashed process
+	lis.(*net.UnixListener).SetUnlinkOnClose(false)
+	s.Require().NoError(lis.Close())
+
+	lis, err = listen("unix://" + path)
+	s.Require().NoError(err)
+	s.Require().NoError(lis.Close())
+}
+
+func (s *tlsSuite) TestMTLS() {
+	servCert, servKey := s.issue(2, "server", s.ca, s.caKey)
+	servFile := s.write("server.pem", servCert, servKey)
+	cliCert, cliKey := s.issue(3, "client", s.ca, s.caKey)
+	cliFile := s.write("client.pem", cliCert, cliKey)
+
+	addr := "unix://" + filepath.Join(s.dir, "grpc.sock")
+	app := bootkit.New()
+
+	app.RegisterServer("grpc-server", func(shutdown bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
+		return RunGrpcServer(context.Background(), addr, shutdown,
+			func(serv *grpc.Server) {
+				healthpb.RegisterHealthServer(serv, health.NewServer())
+			},
+			WithBootApp(app),
+			WithTLS(servFile, servFile+".key"),
+			WithClientCA(s.caFile),
+			WithReady(ready),
+		)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(app.Run(ctx))
+
+	check := func(l *certLoader) error {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(l.clientConfig("localhost"))))
+		s.Require().NoError(err)
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		return err
+	}
+
+	withCert, err := newCertLoader(cliFile, cliFile+".key", s.caFile)
+	s.Require().NoError(err)
+	s.Require().NoError(check(withCert))
+
+	withoutCert, err := newCertLoader("", "", s.caFile)
+	s.Require().NoError(err)
+	s.Require().Error(check(withoutCert))
+
+	// the renewed certificate is served after reload
+	renewed, renewedKey := s.issue(4, "server", s.ca, s.caKey)
+	s.write("server.pem", renewed, renewedKey)
+	s.Require().NoError(app.Reload(context.Background()))
+
+	conn, err := tls.Dial("unix", filepath.Join(s.dir, "grpc.sock"), withCert.clientConfig("localhost"))
+	s.Require().NoError(err)
+	s.Require().NoError(conn.Handshake())
+	s.Require().Equal(int64(4), conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64())
+	s.Require().NoError(conn.Close())
+
+	cancel()
+	app.Wait()
+}
+
+func (s *tlsSuite) TestReloadDialCA() {
+	// the server is issued by the rotated CA, which the client doesn't trust until reload
+	rotated, rotatedKey := s.issue(5, "rotated-ca", nil, nil)
+	servCert, servKey := s.issue(6, "server", rotated, rotatedKey)
+
+	sock := filepath.Join(s.dir, "tls.sock")
+	lis, err := tls.Listen("unix", sock, &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{{Certificate: [][]byte{servCert.Raw}, PrivateKey: servKey}},
+	})
+	s.Require().NoError(err)
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	l, err := newCertLoader("", "", s.caFile)
+	s.Require().NoError(err)
+	conf := l.clientConfig("localhost")
+	dial := func(conf *tls.Config) error {
+		conn, err := tls.Dial("unix", sock, conf)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	s.Require().Error(dial(conf))
+
+	// the config built before reload trusts the rotated CA
+	s.write("ca.pem", rotated, nil)
+	s.Require().NoError(l.Reload(context.Background()))
+	s.Require().NoError(dial(conf))
+
+	// the server name is still verified
+	s.Require().Error(dial(l.clientConfig("example.com")))
+}
+
+func (s *tlsSuite) TestReloadInvalid() {
+	servCert, servKey := s.issue(2, "server", s.ca, s.caKey)
+	servFile := s.write("server.pem", servCert, servKey)
+
+	l, err := newCertLoader(servFile, servFile+".key", s.caFile)
+	s.Require().NoError(err)
+
+	// the previous certificate is kept
+	s.Require().NoError(os.WriteFile(s.caFile, []byte("broken"), 0o600))
+	s.Require().ErrorIs(l.Reload(context.Background()), ErrInvalidCA)
+	s.Require().NotNil(l.cert.Load())
+	s.Require().NotNil(l.pool.Load())
+}
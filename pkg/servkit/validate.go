@@ -0,0 +1,137 @@
+package servkit
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validator is implemented by messages generated by protoc-gen-validate.
+type validator interface {
+	Validate() error
+}
+
+// allValidator is preferred over validator to report every violation at once.
+type allValidator interface {
+	ValidateAll() error
+}
+
+// fieldError is implemented by the per-field errors of protoc-gen-validate.
+type fieldError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+// multiError is implemented by the errors returned by ValidateAll.
+type multiError interface {
+	AllErrors() []error
+}
+
+// ValidationInterceptor rejects requests violating their protoc-gen-validate rules with codes.InvalidArgument,
+// attaching the violations as errdetails.BadRequest.
+func ValidationInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// ValidationStreamInterceptor validates every message received on the stream like ValidationInterceptor.
+func ValidationStreamInterceptor(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	return handler(srv, &validatingStream{ServerStream: ss})
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return validate(m)
+}
+
+func validate(m interface{}) error {
+	var err error
+	switch v := m.(type) {
+	case allValidator:
+		err = v.ValidateAll()
+	case validator:
+		err = v.Validate()
+	default:
+		return nil
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	st := status.New(codes.InvalidArgument, err.Error())
+	if br := badRequest(err); len(br.FieldViolations) > 0 {
+		if ds, dErr := st.WithDetails(br); dErr == nil {
+			st = ds
+		}
+	}
+
+	return st.Err()
+}
+
+func badRequest(err error) *errdetails.BadRequest {
+	br := &errdetails.BadRequest{}
+
+	var me multiError
+	if errors.As(err, &me) {
+		for _, e := range me.AllErrors() {
+			br.FieldViolations = append(br.FieldViolations, fieldViolations("", e)...)
+		}
+		return br
+	}
+
+	br.FieldViolations = fieldViolations("", err)
+	return br
+}
+
+// fieldViolations flattens nested validation errors into violations with dotted field paths, e.g. "Item[0].ItemId".
+func fieldViolations(prefix string, err error) []*errdetails.BadRequest_FieldViolation {
+	var fe fieldError
+	if !errors.As(err, &fe) {
+		return nil
+	}
+
+	field := fe.Field()
+	if prefix != "" {
+		field = prefix + "." + field
+	}
+
+	if cause := fe.Cause(); cause != nil {
+		var me multiError
+		if errors.As(cause, &me) {
+			var vs []*errdetails.BadRequest_FieldViolation
+			for _, e := range me.AllErrors() {
+				vs = append(vs, fieldViolations(field, e)...)
+			}
+			if len(vs) > 0 {
+				return vs
+			}
+		} else if vs := fieldViolations(field, cause); len(vs) > 0 {
+			return vs
+		}
+	}
+
+	return []*errdetails.BadRequest_FieldViolation{{
+		Field:       field,
+		Description: fe.Reason(),
+	}}
+}
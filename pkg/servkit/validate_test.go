@@ -0,0 +1,164 @@
+package servkit
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"demo/pkg/bootkit"
+	pb "demo/proto/example"
+)
+
+type validateSuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (s *validateSuite) SetupSuite() {
+	dir, err := os.MkdirTemp("", "validate")
+	s.Require().NoError(err)
+	s.dir = dir
+}
+
+func (s *validateSuite) TearDownSuite() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *validateSuite) SetupTest()    {}
+func (s *validateSuite) TearDownTest() {}
+
+func TestValidateSuite(t *testing.T) {
+	suite.Run(t, new(validateSuite))
+}
+
+type exampleServer struct {
+	*pb.UnimplementedExampleServer
+}
+
+func (exampleServer) Login(context.Context, *pb.LoginReq) (*pb.LoginResp, error) {
+	return &pb.LoginResp{Status: "success"}, nil
+}
+
+func (s *validateSuite) TestValidationInterceptor() {
+	tests := []struct {
+		Desc          string
+		Req           interface{}
+		ExpCode       codes.Code
+		ExpViolations map[string]string
+	}{
+		{
+			Desc:    "valid",
+			Req:     &pb.LoginReq{Username: "user", Password: "pass"},
+			ExpCode: codes.OK,
+		},
+		{
+			Desc:    "all violations",
+			Req:     &pb.LoginReq{},
+			ExpCode: codes.InvalidArgument,
+			ExpViolations: map[string]string{
+				"Username": "value length must be at least 1 runes",
+				"Password": "value length must be at least 1 runes",
+			},
+		},
+		{
+			Desc:    "without rules",
+			Req:     "plain",
+			ExpCode: codes.OK,
+		},
+	}
+
+	for _, t := range tests {
+		_, err := ValidationInterceptor(context.Background(), t.Req, &grpc.UnaryServerInfo{},
+			func(context.Context, interface{}) (interface{}, error) { return nil, nil },
+		)
+		s.Require().Equal(t.ExpCode, status.Code(err), t.Desc)
+
+		violations := map[string]string{}
+		for _, d := range status.Convert(err).Details() {
+			br, ok := d.(*errdetails.BadRequest)
+			s.Require().True(ok, t.Desc)
+			for _, v := range br.GetFieldViolations() {
+				violations[v.GetField()] = v.GetDescription()
+			}
+		}
+		if t.ExpViolations == nil {
+			s.Require().Empty(violations, t.Desc)
+		} else {
+			s.Require().Equal(t.ExpViolations, violations, t.Desc)
+		}
+	}
+}
+
+// serveExample serves exampleServer with the gateway over a unix socket in dir,
+// and returns the client of the gateway along with the function stopping the server.
+func serveExample(s *suite.Suite, dir string, options ...ServOptions) (*http.Client, func()) {
+	sock := filepath.Join(dir, "mux.sock")
+	app := bootkit.New()
+	app.RegisterServer("mux-server", func(shutdown bootkit.ShutdownFunc, ready bootkit.ReadyFunc) error {
+		return RunMuxServer(context.Background(), "unix://"+sock, shutdown,
+			func(serv *grpc.Server) {
+				pb.RegisterExampleServer(serv, exampleServer{})
+			},
+			func(ctx context.Context, mux *gwruntime.ServeMux, conn *grpc.ClientConn) error {
+				return pb.RegisterExampleHandler(ctx, mux, conn)
+			},
+			append(options, WithBootApp(app), WithReady(ready))...,
+		)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(app.Run(ctx))
+
+	cli := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+			},
+		},
+		Timeout: time.Second,
+	}
+
+	return cli, func() {
+		cancel()
+		app.Wait()
+	}
+}
+
+func (s *validateSuite) TestGatewayBadRequest() {
+	cli, stop := serveExample(&s.Suite, s.dir)
+	defer stop()
+
+	resp, err := cli.Post("http://localhost/v1/login", "application/json", strings.NewReader(`{"Username":"user"}`))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+
+	var body struct {
+		Code    int `json:"code"`
+		Details []struct {
+			FieldViolations []struct {
+				Field       string `json:"field"`
+				Description string `json:"description"`
+			} `json:"fieldViolations"`
+		} `json:"details"`
+	}
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&body))
+	s.Require().Equal(int(codes.InvalidArgument), body.Code)
+	s.Require().Len(body.Details, 1)
+	s.Require().Len(body.Details[0].FieldViolations, 1)
+	s.Require().Equal("Password", body.Details[0].FieldViolations[0].Field)
+}
@@ -0,0 +1,5 @@
+// Package telemetrykit traces requests with the active Provider, which is New Relic, OpenTelemetry or a no-op one.
+// Traces are propagated in W3C `traceparent` headers across the gateway, gRPC and outbound HTTP calls,
+// and the IDs of the current span are logged with each request, whichever provider is active.
+// The no-op provider still propagates traces without exporting them, ex: in local and CI.
+package telemetrykit
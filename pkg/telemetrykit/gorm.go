@@ -0,0 +1,83 @@
+package telemetrykit
+
+import (
+	"errors"
+
+	"github.com/rafaelhl/gorm-newrelic-telemetry-plugin/telemetry"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "telemetrykit:span"
+
+// GormPlugin returns the GORM plugin tracing queries with the provider.
+// It's the New Relic datastore tracer for NewNewRelic(), or client spans named after the operation otherwise.
+func GormPlugin(p Provider, dbName, addr string) gorm.Plugin {
+	if _, ok := p.(*nrProvider); ok {
+		// Host is the name of the server hosting the datastore, and "MySQL" is the product name defined by New Relic.
+		return telemetry.NewNrTracer(dbName, addr, "MySQL")
+	}
+
+	return &gormTracer{provider: p, dbName: dbName}
+}
+
+type gormTracer struct {
+	provider Provider
+	dbName   string
+}
+
+func (t *gormTracer) Name() string {
+	return "telemetrykit:" + t.dbName
+}
+
+func (t *gormTracer) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, reg := range []struct {
+		op     string
+		before func(string, func(*gorm.DB)) error
+		after  func(string, func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	} {
+		if err := reg.before(t.Name()+":before_"+reg.op, t.before(reg.op)); err != nil {
+			return err
+		}
+		if err := reg.after(t.Name()+":after_"+reg.op, t.after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *gormTracer) before(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := t.provider.Start(db.Statement.Context, "gorm."+op, SpanKindClient, nil)
+		span.SetAttribute("db.system", "mysql")
+		span.SetAttribute("db.name", t.dbName)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (t *gormTracer) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := v.(Span)
+
+	// the SQL is parameterized, so values aren't recorded
+	span.SetAttribute("db.statement", db.Statement.SQL.String())
+	if table := db.Statement.Table; table != "" {
+		span.SetAttribute("db.sql.table", table)
+	}
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+	}
+	span.End()
+}
@@ -0,0 +1,13 @@
+//go:generate go-enum -f=$GOFILE --nocase
+
+package telemetrykit
+
+// SpanKind is an enumeration of the roles of spans in a trace.
+/*
+ENUM(
+Internal // Internal operations of the service.
+Server // Incoming requests, which continue the trace propagated by the client.
+Client // Outbound requests, which propagate the trace to the server.
+)
+*/
+type SpanKind int32
@@ -0,0 +1,64 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package telemetrykit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// SpanKindInternal is a SpanKind of type Internal.
+	// Internal operations of the service.
+	SpanKindInternal SpanKind = iota
+	// SpanKindServer is a SpanKind of type Server.
+	// Incoming requests, which continue the trace propagated by the client.
+	SpanKindServer
+	// SpanKindClient is a SpanKind of type Client.
+	// Outbound requests, which propagate the trace to the server.
+	SpanKindClient
+)
+
+var ErrInvalidSpanKind = errors.New("not a valid SpanKind")
+
+const _SpanKindName = "InternalServerClient"
+
+var _SpanKindMap = map[SpanKind]string{
+	SpanKindInternal: _SpanKindName[0:8],
+	SpanKindServer:   _SpanKindName[8:14],
+	SpanKindClient:   _SpanKindName[14:20],
+}
+
+// String implements the Stringer interface.
+func (x SpanKind) String() string {
+	if str, ok := _SpanKindMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("SpanKind(%d)", x)
+}
+
+var _SpanKindValue = map[string]SpanKind{
+	_SpanKindName[0:8]:                    SpanKindInternal,
+	strings.ToLower(_SpanKindName[0:8]):   SpanKindInternal,
+	_SpanKindName[8:14]:                   SpanKindServer,
+	strings.ToLower(_SpanKindName[8:14]):  SpanKindServer,
+	_SpanKindName[14:20]:                  SpanKindClient,
+	strings.ToLower(_SpanKindName[14:20]): SpanKindClient,
+}
+
+// ParseSpanKind attempts to convert a string to a SpanKind.
+func ParseSpanKind(name string) (SpanKind, error) {
+	if x, ok := _SpanKindValue[name]; ok {
+		return x, nil
+	}
+	// Case insensitive parse, do a separate lookup to prevent unnecessary cost of lowercasing a string if we don't need to.
+	if x, ok := _SpanKindValue[strings.ToLower(name)]; ok {
+		return x, nil
+	}
+	return SpanKind(0), fmt.Errorf("%s is %w", name, ErrInvalidSpanKind)
+}
@@ -0,0 +1,116 @@
+package telemetrykit
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// NewNewRelic returns a Provider recording transactions in New Relic. Transactions are put into the context
+// by newrelic.NewContext() as well, so integrations of New Relic, ex: the GORM tracer, keep working.
+// Traces are propagated in both W3C and New Relic headers.
+func NewNewRelic(app *newrelic.Application) Provider {
+	return &nrProvider{app: app}
+}
+
+type nrProvider struct {
+	app *newrelic.Application
+}
+
+func (p *nrProvider) Start(ctx context.Context, name string, kind SpanKind, carrier Carrier) (context.Context, Span) {
+	txn := newrelic.FromContext(ctx)
+	if kind == SpanKindServer || txn == nil {
+		txn = p.app.StartTransaction(name)
+		if kind == SpanKindServer && carrier != nil {
+			txn.AcceptDistributedTraceHeaders(newrelic.TransportHTTP, toHeader(carrier))
+		}
+
+		s := &nrSpan{txn: txn}
+		return ContextWithSpan(newrelic.NewContext(ctx, txn), s), s
+	}
+
+	s := &nrSpan{txn: txn, seg: txn.StartSegment(name)}
+	return ContextWithSpan(ctx, s), s
+}
+
+// Shutdown flushes data to New Relic until the deadline of ctx, or 10s if there's none.
+func (p *nrProvider) Shutdown(ctx context.Context) error {
+	timeout := 10 * time.Second
+	if dl, ok := ctx.Deadline(); ok {
+		timeout = time.Until(dl)
+	}
+	p.app.Shutdown(timeout)
+
+	return nil
+}
+
+// nrSpan is a transaction, or a segment of the transaction if seg isn't nil.
+type nrSpan struct {
+	txn *newrelic.Transaction
+	seg *newrelic.Segment
+}
+
+func (s *nrSpan) TraceID() string {
+	return s.txn.GetTraceMetadata().TraceID
+}
+
+func (s *nrSpan) SpanID() string {
+	return s.txn.GetTraceMetadata().SpanID
+}
+
+func (s *nrSpan) SetName(name string) {
+	if s.seg == nil {
+		s.txn.SetName(name)
+	}
+}
+
+func (s *nrSpan) SetAttribute(key string, value interface{}) {
+	if s.seg != nil {
+		s.seg.AddAttribute(key, value)
+		return
+	}
+	s.txn.AddAttribute(key, value)
+}
+
+func (s *nrSpan) RecordError(err error) {
+	s.txn.NoticeError(err)
+}
+
+func (s *nrSpan) Inject(carrier Carrier) {
+	h := http.Header{}
+	s.txn.InsertDistributedTraceHeaders(h)
+	for k := range h {
+		carrier.Set(k, h.Get(k))
+	}
+}
+
+func (s *nrSpan) End() {
+	if s.seg != nil {
+		s.seg.End()
+		return
+	}
+	s.txn.End()
+}
+
+func (s *nrSpan) SetWebRequest(r *http.Request) {
+	if s.seg == nil {
+		s.txn.SetWebRequestHTTP(r)
+	}
+}
+
+func (s *nrSpan) SetWebResponse(w http.ResponseWriter) http.ResponseWriter {
+	if s.seg == nil {
+		return s.txn.SetWebResponse(w)
+	}
+	return w
+}
+
+func toHeader(carrier Carrier) http.Header {
+	h := http.Header{}
+	for _, k := range carrier.Keys() {
+		h.Set(k, carrier.Get(k))
+	}
+	return h
+}
@@ -0,0 +1,100 @@
+package telemetrykit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TraceParent is the header of W3C trace context.
+// ref: https://www.w3.org/TR/trace-context/#traceparent-header
+const TraceParent = "traceparent"
+
+// NewNoop returns a Provider which exports nothing, but still propagates W3C trace context,
+// so logs of services are correlated by trace IDs without a telemetry backend.
+func NewNoop() Provider {
+	return noopProvider{}
+}
+
+type noopProvider struct{}
+
+func (noopProvider) Start(ctx context.Context, _ string, kind SpanKind, carrier Carrier) (context.Context, Span) {
+	s := &noopSpan{spanID: randomHex(8), flags: "01"}
+
+	parent := SpanFromContext(ctx)
+	s.traceID = parent.TraceID()
+	if p, ok := parent.(*noopSpan); ok {
+		s.flags = p.flags
+	}
+	if kind == SpanKindServer && carrier != nil {
+		if traceID, _, flags, ok := parseTraceParent(carrier.Get(TraceParent)); ok {
+			s.traceID, s.flags = traceID, flags
+		}
+	}
+	if s.traceID == "" {
+		s.traceID = randomHex(16)
+	}
+
+	return ContextWithSpan(ctx, s), s
+}
+
+func (noopProvider) Shutdown(context.Context) error {
+	return nil
+}
+
+type noopSpan struct {
+	traceID, spanID, flags string
+}
+
+func (s *noopSpan) TraceID() string                  { return s.traceID }
+func (s *noopSpan) SpanID() string                   { return s.spanID }
+func (s *noopSpan) SetName(string)                   {}
+func (s *noopSpan) SetAttribute(string, interface{}) {}
+func (s *noopSpan) RecordError(error)                {}
+func (s *noopSpan) End()                             {}
+
+func (s *noopSpan) Inject(carrier Carrier) {
+	carrier.Set(TraceParent, fmt.Sprintf("00-%s-%s-%s", s.traceID, s.spanID, s.flags))
+}
+
+// parseTraceParent parses the traceparent header, ex: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func parseTraceParent(v string) (traceID, spanID, flags string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || parts[0] == "ff" || len(parts[0]) != 2 {
+		return "", "", "", false
+	}
+	// version 00 has exactly 4 parts, while future versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", "", false
+	}
+
+	traceID, spanID, flags = parts[1], parts[2], parts[3]
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) {
+		return "", "", "", false
+	}
+	if traceID == strings.Repeat("0", 32) || spanID == strings.Repeat("0", 16) {
+		return "", "", "", false
+	}
+
+	return traceID, spanID, flags, true
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,66 @@
+package telemetrykit
+
+import (
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// OTelOptions sets the OpenTelemetry provider options.
+type OTelOptions interface {
+	apply(*otelOptions)
+}
+
+type otelOptions struct {
+	exporter    sdktrace.SpanExporter
+	serviceName string
+	sampleRatio float64
+}
+
+// funcOTelOption wraps a function that modifies otelOptions into an
+// implementation of the OTelOptions interface.
+type funcOTelOption struct {
+	f func(*otelOptions)
+}
+
+func (o *funcOTelOption) apply(do *otelOptions) {
+	o.f(do)
+}
+
+func newFuncOTelOption(f func(*otelOptions)) *funcOTelOption {
+	return &funcOTelOption{
+		f: f,
+	}
+}
+
+// WithSpanExporter specifies the exporter of spans, ex: tracetest.NewInMemoryExporter() in tests.
+// It's the OTLP gRPC exporter configured by OTEL_EXPORTER_OTLP_* environment variables by default.
+func WithSpanExporter(exporter sdktrace.SpanExporter) OTelOptions {
+	return newFuncOTelOption(func(opts *otelOptions) {
+		opts.exporter = exporter
+	})
+}
+
+// WithServiceName specifies service.name of the resource, it's OTEL_SERVICE_NAME by default.
+func WithServiceName(name string) OTelOptions {
+	return newFuncOTelOption(func(opts *otelOptions) {
+		opts.serviceName = name
+	})
+}
+
+// WithSampleRatio samples the ratio of new traces, while traces propagated by clients follow their sampling decision.
+// It's 1, i.e., all traces, by default.
+func WithSampleRatio(ratio float64) OTelOptions {
+	return newFuncOTelOption(func(opts *otelOptions) {
+		opts.sampleRatio = ratio
+	})
+}
+
+func applyOTelOptions(options ...OTelOptions) *otelOptions {
+	opts := &otelOptions{
+		sampleRatio: 1,
+	}
+	for _, o := range options {
+		o.apply(opts)
+	}
+
+	return opts
+}
@@ -0,0 +1,134 @@
+package telemetrykit
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "demo/pkg/telemetrykit"
+
+// NewOTel returns a Provider exporting spans with OpenTelemetry, which are batched and sent
+// by the OTLP gRPC exporter by default. Call Shutdown() to flush them before the process exits.
+func NewOTel(ctx context.Context, options ...OTelOptions) (Provider, error) {
+	o := applyOTelOptions(options...)
+
+	if o.exporter == nil {
+		exp, err := otlptracegrpc.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		o.exporter = exp
+	}
+
+	res := resource.Default()
+	if o.serviceName != "" {
+		var err error
+		res, err = resource.Merge(res, resource.NewSchemaless(attribute.String("service.name", o.serviceName)))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(o.exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(o.sampleRatio))),
+	)
+
+	return &otelProvider{
+		tp:         tp,
+		tracer:     tp.Tracer(tracerName),
+		propagator: propagation.TraceContext{},
+	}, nil
+}
+
+type otelProvider struct {
+	tp         *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func (p *otelProvider) Start(ctx context.Context, name string, kind SpanKind, carrier Carrier) (context.Context, Span) {
+	otelKind := trace.SpanKindInternal
+	switch kind {
+	case SpanKindServer:
+		otelKind = trace.SpanKindServer
+		if carrier != nil {
+			ctx = p.propagator.Extract(ctx, carrier)
+		}
+	case SpanKindClient:
+		otelKind = trace.SpanKindClient
+	}
+
+	ctx, span := p.tracer.Start(ctx, name, trace.WithSpanKind(otelKind))
+	s := &otelSpan{span: span, propagator: p.propagator}
+
+	return ContextWithSpan(ctx, s), s
+}
+
+func (p *otelProvider) Shutdown(ctx context.Context) error {
+	return p.tp.Shutdown(ctx)
+}
+
+type otelSpan struct {
+	span       trace.Span
+	propagator propagation.TextMapPropagator
+}
+
+func (s *otelSpan) TraceID() string {
+	if sc := s.span.SpanContext(); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+func (s *otelSpan) SpanID() string {
+	if sc := s.span.SpanContext(); sc.HasSpanID() {
+		return sc.SpanID().String()
+	}
+	return ""
+}
+
+func (s *otelSpan) SetName(name string) {
+	s.span.SetName(name)
+}
+
+func (s *otelSpan) SetAttribute(key string, value interface{}) {
+	var kv attribute.KeyValue
+	switch v := value.(type) {
+	case string:
+		kv = attribute.String(key, v)
+	case int:
+		kv = attribute.Int(key, v)
+	case int64:
+		kv = attribute.Int64(key, v)
+	case float64:
+		kv = attribute.Float64(key, v)
+	case bool:
+		kv = attribute.Bool(key, v)
+	default:
+		kv = attribute.String(key, fmt.Sprint(v))
+	}
+	s.span.SetAttributes(kv)
+}
+
+func (s *otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *otelSpan) Inject(carrier Carrier) {
+	s.propagator.Inject(trace.ContextWithSpan(context.Background(), s.span), carrier)
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}
@@ -0,0 +1,148 @@
+package telemetrykit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Provider starts spans of the telemetry backend.
+type Provider interface {
+	// Start starts the span as a child of the span in ctx, or as the root of a new trace if there's none.
+	// Server spans continue the trace propagated in the carrier instead, ex: `traceparent` in request headers.
+	Start(ctx context.Context, name string, kind SpanKind, carrier Carrier) (context.Context, Span)
+	// Shutdown flushes buffered spans.
+	Shutdown(ctx context.Context) error
+}
+
+// Span is a traced operation.
+type Span interface {
+	// TraceID returns the hex ID of the trace, which is empty if the provider doesn't assign one.
+	TraceID() string
+	// SpanID returns the hex ID of the span, which is empty if the provider doesn't assign one.
+	SpanID() string
+	// SetName renames the span, ex: once the route of the request is matched.
+	SetName(name string)
+	SetAttribute(key string, value interface{})
+	// RecordError marks the span failed with the error.
+	RecordError(err error)
+	// Inject propagates the trace into the carrier, ex: headers of an outbound request.
+	Inject(carrier Carrier)
+	End()
+}
+
+// WebSpan is implemented by spans recording HTTP requests and responses natively, ex: New Relic web transactions.
+type WebSpan interface {
+	SetWebRequest(r *http.Request)
+	// SetWebResponse returns the writer recording the response, which must be used afterwards.
+	SetWebResponse(w http.ResponseWriter) http.ResponseWriter
+}
+
+// Carrier carries propagated traces, it's compatible with propagation.TextMapCarrier of OpenTelemetry.
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+	Keys() []string
+}
+
+// HeaderCarrier carries traces in HTTP headers.
+type HeaderCarrier http.Header
+
+func (c HeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+func (c HeaderCarrier) Set(key, value string) {
+	http.Header(c).Set(key, value)
+}
+
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// MetadataCarrier carries traces in gRPC metadata.
+type MetadataCarrier metadata.MD
+
+func (c MetadataCarrier) Get(key string) string {
+	if vals := metadata.MD(c).Get(key); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+func (c MetadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c MetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, strings.ToLower(k))
+	}
+	return keys
+}
+
+var (
+	defaultMux      sync.RWMutex
+	defaultProvider Provider = NewNoop()
+)
+
+// Default returns the provider used by the package-level functions, it's NewNoop() until SetDefault() is called.
+func Default() Provider {
+	defaultMux.RLock()
+	defer defaultMux.RUnlock()
+
+	return defaultProvider
+}
+
+// SetDefault replaces the provider used by the package-level functions.
+func SetDefault(p Provider) {
+	defaultMux.Lock()
+	defer defaultMux.Unlock()
+
+	defaultProvider = p
+}
+
+// Start starts the span with the default provider, see Provider.Start().
+func Start(ctx context.Context, name string, kind SpanKind, carrier Carrier) (context.Context, Span) {
+	return Default().Start(ctx, name, kind, carrier)
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns the context carrying the span, which is done by providers in Start().
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the current span, or a span doing nothing if there's none.
+func SpanFromContext(ctx context.Context) Span {
+	if s, ok := ctx.Value(spanKey{}).(Span); ok {
+		return s
+	}
+	return emptySpan{}
+}
+
+// TraceIDs returns the IDs of the trace and the current span, which are empty if there's no span.
+func TraceIDs(ctx context.Context) (traceID, spanID string) {
+	s := SpanFromContext(ctx)
+	return s.TraceID(), s.SpanID()
+}
+
+// emptySpan is returned by SpanFromContext() if there's no span.
+type emptySpan struct{}
+
+func (emptySpan) TraceID() string                  { return "" }
+func (emptySpan) SpanID() string                   { return "" }
+func (emptySpan) SetName(string)                   {}
+func (emptySpan) SetAttribute(string, interface{}) {}
+func (emptySpan) RecordError(error)                {}
+func (emptySpan) Inject(Carrier)                   {}
+func (emptySpan) End()                             {}
@@ -0,0 +1,159 @@
+package telemetrykit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+	traceParent   = "00-" + parentTraceID + "-" + parentSpanID + "-01"
+)
+
+type telemetrySuite struct {
+	suite.Suite
+}
+
+func (s *telemetrySuite) SetupSuite()    {}
+func (s *telemetrySuite) TearDownSuite() {}
+func (s *telemetrySuite) SetupTest()     {}
+func (s *telemetrySuite) TearDownTest()  {}
+
+func TestTelemetrySuite(t *testing.T) {
+	suite.Run(t, new(telemetrySuite))
+}
+
+func (s *telemetrySuite) TestParseTraceParent() {
+	tests := []struct {
+		Desc       string
+		Value      string
+		ExpTraceID string
+		ExpOK      bool
+	}{
+		{
+			Desc:       "valid",
+			Value:      traceParent,
+			ExpTraceID: parentTraceID,
+			ExpOK:      true,
+		},
+		{
+			Desc:       "future version with more fields",
+			Value:      "01-" + parentTraceID + "-" + parentSpanID + "-01-extra",
+			ExpTraceID: parentTraceID,
+			ExpOK:      true,
+		},
+		{
+			Desc:  "extra fields of version 00",
+			Value: traceParent + "-extra",
+		},
+		{
+			Desc:  "zero trace id",
+			Value: "00-" + strings.Repeat("0", 32) + "-" + parentSpanID + "-01",
+		},
+		{
+			Desc:  "upper case",
+			Value: strings.ToUpper(traceParent),
+		},
+		{
+			Desc:  "empty",
+			Value: "",
+		},
+	}
+
+	for _, t := range tests {
+		traceID, _, _, ok := parseTraceParent(t.Value)
+		s.Require().Equal(t.ExpOK, ok, t.Desc)
+		s.Require().Equal(t.ExpTraceID, traceID, t.Desc)
+	}
+}
+
+func (s *telemetrySuite) TestNoop() {
+	p := NewNoop()
+
+	tests := []struct {
+		Desc       string
+		Header     http.Header
+		ExpTraceID string
+	}{
+		{
+			Desc:       "continue the propagated trace",
+			Header:     http.Header{"Traceparent": {traceParent}},
+			ExpTraceID: parentTraceID,
+		},
+		{
+			Desc:   "new trace",
+			Header: http.Header{"Traceparent": {"invalid"}},
+		},
+	}
+
+	for _, t := range tests {
+		ctx, server := p.Start(context.Background(), "GET /v1/item", SpanKindServer, HeaderCarrier(t.Header))
+		s.Require().Len(server.TraceID(), 32, t.Desc)
+		s.Require().Len(server.SpanID(), 16, t.Desc)
+		if t.ExpTraceID != "" {
+			s.Require().Equal(t.ExpTraceID, server.TraceID(), t.Desc)
+		}
+
+		traceID, spanID := TraceIDs(ctx)
+		s.Require().Equal(server.TraceID(), traceID, t.Desc)
+		s.Require().Equal(server.SpanID(), spanID, t.Desc)
+
+		// outbound calls carry the trace with the span of the client
+		_, client := p.Start(ctx, "GET example.com", SpanKindClient, nil)
+		s.Require().Equal(server.TraceID(), client.TraceID(), t.Desc)
+		s.Require().NotEqual(server.SpanID(), client.SpanID(), t.Desc)
+
+		out := http.Header{}
+		client.Inject(HeaderCarrier(out))
+		s.Require().Equal("00-"+client.TraceID()+"-"+client.SpanID()+"-01", out.Get(TraceParent), t.Desc)
+	}
+}
+
+func (s *telemetrySuite) TestOTel() {
+	exp := tracetest.NewInMemoryExporter()
+	p, err := NewOTel(context.Background(), WithSpanExporter(exp), WithServiceName("demo-api"))
+	s.Require().NoError(err)
+	defer p.Shutdown(context.Background())
+
+	ctx, server := p.Start(context.Background(), "GET /v1/item", SpanKindServer,
+		MetadataCarrier{"traceparent": {traceParent}})
+	s.Require().Equal(parentTraceID, server.TraceID())
+
+	_, client := p.Start(ctx, "GET example.com", SpanKindClient, nil)
+	out := http.Header{}
+	client.Inject(HeaderCarrier(out))
+	s.Require().Equal("00-"+parentTraceID+"-"+client.SpanID()+"-01", out.Get(TraceParent))
+
+	client.End()
+	server.SetName("GET /v1/item/{id}")
+	server.End()
+	s.Require().NoError(p.(*otelProvider).tp.ForceFlush(context.Background()))
+
+	spans := exp.GetSpans()
+	s.Require().Len(spans, 2)
+	s.Require().Equal("GET example.com", spans[0].Name)
+	s.Require().Equal(trace.SpanKindClient, spans[0].SpanKind)
+	s.Require().Equal(server.SpanID(), spans[0].Parent.SpanID().String())
+	s.Require().Equal("GET /v1/item/{id}", spans[1].Name)
+	s.Require().Equal(trace.SpanKindServer, spans[1].SpanKind)
+	s.Require().Equal(parentSpanID, spans[1].Parent.SpanID().String())
+	s.Require().True(spans[1].Parent.IsRemote())
+}
+
+func (s *telemetrySuite) TestSpanFromContext() {
+	traceID, spanID := TraceIDs(context.Background())
+	s.Require().Empty(traceID)
+	s.Require().Empty(spanID)
+
+	// doesn't panic without a span
+	span := SpanFromContext(context.Background())
+	span.Inject(HeaderCarrier(http.Header{}))
+	span.End()
+}
@@ -0,0 +1,193 @@
+// Code generated by protoc-gen-validate. DO NOT EDIT.
+// source: deadline/deadline.proto
+
+package deadline
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ensure the imports are used
+var (
+	_ = bytes.MinRead
+	_ = errors.New("")
+	_ = fmt.Print
+	_ = utf8.UTFMax
+	_ = (*regexp.Regexp)(nil)
+	_ = (*strings.Reader)(nil)
+	_ = net.IPv4len
+	_ = time.Duration(0)
+	_ = (*url.URL)(nil)
+	_ = (*mail.Address)(nil)
+	_ = anypb.Any{}
+	_ = sort.Sort
+)
+
+// Validate checks the field values on Deadline with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Deadline) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Deadline with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in DeadlineMultiError, or nil
+// if none found.
+func (m *Deadline) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Deadline) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetDefaultTimeout()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, DeadlineValidationError{
+					field:  "DefaultTimeout",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, DeadlineValidationError{
+					field:  "DefaultTimeout",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetDefaultTimeout()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return DeadlineValidationError{
+				field:  "DefaultTimeout",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetMaxTimeout()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, DeadlineValidationError{
+					field:  "MaxTimeout",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, DeadlineValidationError{
+					field:  "MaxTimeout",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetMaxTimeout()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return DeadlineValidationError{
+				field:  "MaxTimeout",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return DeadlineMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeadlineMultiError is an error wrapping multiple validation errors returned
+// by Deadline.ValidateAll() if the designated constraints aren't met.
+type DeadlineMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeadlineMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeadlineMultiError) AllErrors() []error { return m }
+
+// DeadlineValidationError is the validation error returned by
+// Deadline.Validate if the designated constraints aren't met.
+type DeadlineValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeadlineValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeadlineValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeadlineValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeadlineValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeadlineValidationError) ErrorName() string { return "DeadlineValidationError" }
+
+// Error satisfies the builtin error interface
+func (e DeadlineValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeadline.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeadlineValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeadlineValidationError{}
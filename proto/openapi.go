@@ -0,0 +1,10 @@
+// Package proto embeds the OpenAPI specifications generated by protoc-gen-openapiv2,
+// which are served by the gateway with servkit.WithOpenAPI().
+package proto
+
+import "embed"
+
+// OpenAPI holds the `*.swagger.json` files of all services.
+//
+//go:embed */*.swagger.json
+var OpenAPI embed.FS